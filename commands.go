@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	cli2 "github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"regexp"
+	"seed-detect/internal/crawl"
+	"sort"
+)
+
+// evalCommand 使用标注样本评测各个正文抽取策略
+func evalCommand() *cli2.Command {
+	return &cli2.Command{
+		Name:  "eval",
+		Usage: "evaluate extractor strategies over a directory of html fixtures with golden json",
+		Flags: []cli2.Flag{
+			&cli2.StringFlag{
+				Name:     "dir",
+				Usage:    "fixture directory (xxx.html + xxx.json, sub directory as category)",
+				Required: true,
+			},
+			&cli2.StringSliceFlag{
+				Name:  "strategy",
+				Usage: fmt.Sprintf("strategies to evaluate, default all of %v", crawl.ExtractorNames()),
+			},
+			&cli2.BoolFlag{
+				Name:  "json",
+				Usage: "print report as json",
+			},
+		},
+		Action: func(c *cli2.Context) error {
+			fixtures, err := crawl.LoadFixtures(c.String("dir"))
+			if err != nil {
+				return err
+			}
+			if len(fixtures) == 0 {
+				return fmt.Errorf("no fixtures found in %s", c.String("dir"))
+			}
+
+			report, err := crawl.Evaluate(fixtures, c.StringSlice("strategy"))
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("json") {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+			return report.WriteTable(os.Stdout)
+		},
+	}
+}
+
+// induceCommand 从 WARC 或页面目录归纳站点模板，输出 YAML
+func induceCommand() *cli2.Command {
+	return &cli2.Command{
+		Name:  "induce",
+		Usage: "induce site templates from pages sharing the same url pattern",
+		Flags: []cli2.Flag{
+			&cli2.StringFlag{
+				Name:  "warc",
+				Usage: "warc file (.warc or .warc.gz) with crawled pages",
+			},
+			&cli2.StringFlag{
+				Name:  "dir",
+				Usage: "directory of html pages, url taken from xxx.json, canonical link or og:url",
+			},
+			&cli2.StringFlag{
+				Name:  "pattern",
+				Usage: "only use pages whose url matches this regexp, all of them as one group",
+			},
+			&cli2.StringFlag{
+				Name:  "name",
+				Usage: "template name, only used with --pattern",
+			},
+			&cli2.IntFlag{
+				Name:  "min-pages",
+				Value: 3,
+				Usage: "minimum pages of a url pattern to induce a template",
+			},
+			&cli2.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write templates to file instead of stdout",
+			},
+		},
+		Action: func(c *cli2.Context) error {
+			var pages []crawl.Page
+			var err error
+			switch {
+			case c.String("warc") != "":
+				pages, err = crawl.ReadWARC(c.String("warc"))
+			case c.String("dir") != "":
+				pages, err = crawl.LoadPages(c.String("dir"))
+			default:
+				return fmt.Errorf("one of --warc or --dir is required")
+			}
+			if err != nil {
+				return err
+			}
+
+			groups := map[string][]crawl.Page{}
+			if pattern := c.String("pattern"); pattern != "" {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return err
+				}
+				for _, page := range pages {
+					if re.MatchString(page.URL) {
+						groups[pattern] = append(groups[pattern], page)
+					}
+				}
+			} else {
+				groups = crawl.GroupPages(pages)
+			}
+
+			keys := make([]string, 0, len(groups))
+			for key := range groups {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			var templates []*crawl.Template
+			for _, key := range keys {
+				group := groups[key]
+				if key == "" || len(group) < c.Int("min-pages") {
+					continue
+				}
+				opts := crawl.InduceOptions{}
+				if c.String("pattern") != "" {
+					opts.Name = c.String("name")
+					opts.URLPattern = c.String("pattern")
+				}
+				t, err := crawl.InduceTemplate(group, opts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "skip %s (%d pages): %v\n", key, len(group), err)
+					continue
+				}
+				templates = append(templates, t)
+			}
+			if len(templates) == 0 {
+				return fmt.Errorf("no template induced from %d pages", len(pages))
+			}
+
+			var w io.Writer = os.Stdout
+			if output := c.String("output"); output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			encoder := yaml.NewEncoder(w)
+			defer encoder.Close()
+			return encoder.Encode(templates)
+		},
+	}
+}
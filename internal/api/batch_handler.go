@@ -0,0 +1,251 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"path/filepath"
+	"seed-detect/internal/task"
+	"strconv"
+	"strings"
+)
+
+// maxBatchSeeds 单个批量作业最多的种子数
+const maxBatchSeeds = 5000
+
+type BatchHandler struct {
+	manager *task.Manager
+	logger  *zap.Logger
+}
+
+// BatchRequest 批量提交，tasks 中每项与 /task/submit 的参数一致，未填写的字段使用 options 中的值
+type BatchRequest struct {
+	Name    string            `json:"name"`
+	Options json.RawMessage   `json:"options"`
+	Tasks   []json.RawMessage `json:"tasks"`
+}
+
+func NewBatchHandler(manager *task.Manager, logger *zap.Logger) *BatchHandler {
+	return &BatchHandler{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+func (h *BatchHandler) RegisterRouter(server *gin.Engine) {
+	server.POST("/task/batch", h.submitBatch)
+	jobs := server.Group("/jobs")
+	jobs.GET("", h.listJobs)
+	jobs.GET("/:id", h.getJob)
+}
+
+// submitBatch 批量提交任务：json 请求体，或以 multipart 上传 csv/jsonl 文件（file），
+// 共用参数放在 options 表单字段（json），作业名称放在 name 表单字段
+func (h *BatchHandler) submitBatch(ctx *gin.Context) {
+	var (
+		name  string
+		infos []TaskInfo
+		err   error
+	)
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		name = ctx.PostForm("name")
+		infos, err = h.parseUpload(ctx)
+	} else {
+		var req BatchRequest
+		if err = ctx.ShouldBindJSON(&req); err == nil {
+			name = req.Name
+			infos, err = mergeTaskInfos(req.Options, req.Tasks)
+		}
+	}
+	if err == nil && len(infos) == 0 {
+		err = errors.New("没有需要提交的任务")
+	}
+	if err == nil && len(infos) > maxBatchSeeds {
+		err = fmt.Errorf("单次最多提交 %d 个任务", maxBatchSeeds)
+	}
+	var seeds []task.Seed
+	for i := 0; err == nil && i < len(infos); i++ {
+		var seed task.Seed
+		seed.URL = infos[i].Url
+		if seed.Options, err = infos[i].options(); err == nil && seed.URL == "" {
+			err = errors.New("缺少 url")
+		}
+		if err != nil {
+			err = fmt.Errorf("第 %d 个任务: %w", i+1, err)
+		}
+		seeds = append(seeds, seed)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	job, err := h.manager.SubmitBatch(name, seeds)
+	if err != nil {
+		queueFull(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: job,
+	})
+}
+
+// parseUpload 按扩展名解析上传的 csv 或 jsonl 文件
+func (h *BatchHandler) parseUpload(ctx *gin.Context) ([]TaskInfo, error) {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, errors.New("缺少上传文件")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	defaults := json.RawMessage(ctx.PostForm("options"))
+	var rows []json.RawMessage
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		rows, err = csvRows(file)
+	case ".jsonl", ".ndjson", ".json":
+		rows, err = jsonlRows(file)
+	default:
+		return nil, fmt.Errorf("不支持的文件格式: %s", header.Filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mergeTaskInfos(defaults, rows)
+}
+
+// mergeTaskInfos 以共用参数为底，逐个覆盖每个任务填写的字段
+func mergeTaskInfos(defaults json.RawMessage, rows []json.RawMessage) ([]TaskInfo, error) {
+	if len(bytes.TrimSpace(defaults)) == 0 {
+		defaults = json.RawMessage("{}")
+	}
+	infos := make([]TaskInfo, 0, len(rows))
+	for i, row := range rows {
+		// 每次重新解析共用参数，避免切片字段在任务间共享
+		var info TaskInfo
+		if err := json.Unmarshal(defaults, &info); err != nil {
+			return nil, fmt.Errorf("options 不合法: %w", err)
+		}
+		if err := json.Unmarshal(row, &info); err != nil {
+			return nil, fmt.Errorf("第 %d 个任务不合法: %w", i+1, err)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// jsonlRows 每行一个 TaskInfo，跳过空行
+func jsonlRows(r io.Reader) ([]json.RawMessage, error) {
+	var rows []json.RawMessage
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		rows = append(rows, json.RawMessage(append([]byte(nil), line...)))
+	}
+	return rows, scanner.Err()
+}
+
+// csvColumns csv 支持的列，其余列（如站点名称）忽略
+var csvColumns = map[string]string{
+	"url":      "string",
+	"maxdepth": "int",
+	"maxpages": "int",
+	"robots":   "string",
+	"strategy": "string",
+	"mode":     "string",
+}
+
+// csvRows 首行为表头，必须包含 url 列
+func csvRows(r io.Reader) ([]json.RawMessage, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("csv 解析失败: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	// 列名按 TaskInfo 的 json 字段名匹配，不区分大小写
+	fields := map[int]string{}
+	hasURL := false
+	for i, column := range records[0] {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if _, ok := csvColumns[column]; ok {
+			fields[i] = column
+			hasURL = hasURL || column == "url"
+		}
+	}
+	if !hasURL {
+		return nil, errors.New("csv 缺少 url 列")
+	}
+
+	var rows []json.RawMessage
+	for n, record := range records[1:] {
+		row := map[string]any{}
+		for i, column := range fields {
+			if i >= len(record) || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			value := strings.TrimSpace(record[i])
+			if csvColumns[column] == "int" {
+				v, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("csv 第 %d 行 %s 不合法: %s", n+2, column, value)
+				}
+				row[column] = v
+				continue
+			}
+			row[column] = value
+		}
+		if len(row) == 0 {
+			continue
+		}
+		data, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, data)
+	}
+	return rows, nil
+}
+
+func (h *BatchHandler) listJobs(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: h.manager.ListJobs(),
+	})
+}
+
+// getJob 批量作业及子任务的汇总进度
+func (h *BatchHandler) getJob(ctx *gin.Context) {
+	job, ok := h.manager.GetJob(ctx.Param("id"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, Result{
+			Code: InvalidJobId,
+			Msg:  "批量作业不存在",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: job,
+	})
+}
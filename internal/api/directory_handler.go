@@ -0,0 +1,77 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"seed-detect/internal/crawl"
+	"seed-detect/internal/task"
+)
+
+type DirectoryHandler struct {
+	manager *task.Manager
+	logger  *zap.Logger
+}
+
+// HarvestRequest 目录采集请求，validate 默认开启；submit 为 true 时为可访问的成员站点创建采集任务
+type HarvestRequest struct {
+	Url         string `json:"url"`
+	MaxPages    int    `json:"maxPages"`
+	Validate    *bool  `json:"validate"`
+	Concurrency int    `json:"concurrency"`
+	Submit      bool   `json:"submit"`
+	// 成员站点采集任务配置，字段与 /task/submit 相同，url 忽略
+	Task TaskInfo `json:"task"`
+}
+
+func NewDirectoryHandler(manager *task.Manager, logger *zap.Logger) *DirectoryHandler {
+	return &DirectoryHandler{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+func (h *DirectoryHandler) RegisterRouter(server *gin.Engine) {
+	server.POST("/directory/harvest", h.harvest)
+}
+
+func (h *DirectoryHandler) harvest(ctx *gin.Context) {
+	logger := h.logger.Named("DirectoryHandler harvest")
+	var req HarvestRequest
+
+	if err := ctx.Bind(&req); err != nil || req.Url == "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  "参数不合法",
+		})
+		return
+	}
+
+	opts := crawl.HarvestOptions{
+		MaxPages:    req.MaxPages,
+		Validate:    req.Validate == nil || *req.Validate,
+		Concurrency: req.Concurrency,
+	}
+	taskOpts, err := req.Task.options()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	result, err := h.manager.HarvestDirectory(ctx.Request.Context(), req.Url, opts, taskOpts, req.Submit)
+	if err != nil {
+		logger.Error(err.Error())
+		ctx.JSON(http.StatusOK, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: result,
+	})
+}
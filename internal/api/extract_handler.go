@@ -0,0 +1,122 @@
+package api
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"seed-detect/internal/crawl"
+)
+
+type ExtractHandler struct {
+	templates *crawl.TemplateStore
+	logger    *zap.Logger
+}
+
+// ExtractRequest url 与 html 二选一，html 存在时 url 仅用于解析相对地址
+type ExtractRequest struct {
+	Url    string `json:"url"`
+	Html   string `json:"html"`
+	Format string `json:"format"`
+}
+
+func NewExtractHandler(templates *crawl.TemplateStore, logger *zap.Logger) *ExtractHandler {
+	return &ExtractHandler{
+		templates: templates,
+		logger:    logger,
+	}
+}
+
+// NavigationResponse 导航结构及可作为种子的栏目地址
+type NavigationResponse struct {
+	Navigation *crawl.Navigation `json:"navigation"`
+	Seeds      []string          `json:"seeds"`
+}
+
+func (h *ExtractHandler) RegisterRouter(server *gin.Engine) {
+	server.POST("/extract", h.extract)
+	server.POST("/navigation", h.navigation)
+}
+
+func (h *ExtractHandler) extract(ctx *gin.Context) {
+	logger := h.logger.Named("ExtractHandler extract")
+	var req ExtractRequest
+
+	if err := ctx.Bind(&req); err != nil || (req.Url == "" && req.Html == "") {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  "参数不合法",
+		})
+		return
+	}
+
+	format, err := crawl.ParseOutputFormat(req.Format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	extractor := crawl.NewContentExtractor()
+	extractor.Format = format
+	extractor.Templates = h.templates
+
+	var content *crawl.ExtractedContent
+	if req.Html != "" {
+		content, err = extractor.ExtractFromHTMLWithURL(req.Html, req.Url)
+	} else {
+		content, err = extractor.ExtractFromURL(ctx.Request.Context(), req.Url)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		ctx.JSON(http.StatusOK, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: content,
+	})
+}
+
+// navigation 抽取页面的主导航、页脚链接块和栏目种子
+func (h *ExtractHandler) navigation(ctx *gin.Context) {
+	logger := h.logger.Named("ExtractHandler navigation")
+	var req ExtractRequest
+
+	if err := ctx.Bind(&req); err != nil || (req.Url == "" && req.Html == "") {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  "参数不合法",
+		})
+		return
+	}
+
+	var doc *goquery.Document
+	var err error
+	if req.Html != "" {
+		doc, err = crawl.ParseDocument(req.Html, req.Url)
+	} else {
+		doc, err = crawl.FetchDocument(ctx.Request.Context(), req.Url)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		ctx.JSON(http.StatusOK, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	nav := crawl.NewContentExtractor().ExtractNavigation(doc)
+	ctx.JSON(http.StatusOK, Result{
+		Data: NavigationResponse{
+			Navigation: nav,
+			Seeds:      nav.Seeds(req.Url),
+		},
+	})
+}
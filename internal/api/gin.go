@@ -12,7 +12,7 @@ type Server struct {
 	HttpServer *http.Server
 }
 
-func NewServer(cli *cli2.Context, taskHandler *TaskHandler, logger *zap.Logger) *Server {
+func NewServer(cli *cli2.Context, taskHandler *TaskHandler, extractHandler *ExtractHandler, templateHandler *TemplateHandler, directoryHandler *DirectoryHandler, scheduleHandler *ScheduleHandler, batchHandler *BatchHandler, metricsHandler *MetricsHandler, logger *zap.Logger) *Server {
 
 	handler := gin.Default()
 	// 日志记录（暂时使用中间件记录）
@@ -20,6 +20,12 @@ func NewServer(cli *cli2.Context, taskHandler *TaskHandler, logger *zap.Logger)
 
 	// 业务路由
 	taskHandler.RegisterRouter(handler)
+	extractHandler.RegisterRouter(handler)
+	templateHandler.RegisterRouter(handler)
+	directoryHandler.RegisterRouter(handler)
+	scheduleHandler.RegisterRouter(handler)
+	batchHandler.RegisterRouter(handler)
+	metricsHandler.RegisterRouter(handler)
 
 	addr := fmt.Sprintf("%s:%s", cli.String("host"), cli.String("port"))
 	logger.Info(fmt.Sprintf("listening on -> %s", addr))
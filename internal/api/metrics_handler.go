@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// MetricsHandler 以 Prometheus 文本格式输出监控指标
+type MetricsHandler struct {
+	gatherer prometheus.Gatherer
+	logger   *zap.Logger
+}
+
+func NewMetricsHandler(gatherer prometheus.Gatherer, logger *zap.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		gatherer: gatherer,
+		logger:   logger.Named("MetricsHandler"),
+	}
+}
+
+func (h *MetricsHandler) RegisterRouter(server *gin.Engine) {
+	handler := promhttp.HandlerFor(h.gatherer, promhttp.HandlerOpts{
+		ErrorLog: zap.NewStdLog(h.logger),
+		// 个别指标收集失败时仍输出其余指标
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	server.GET("/metrics", gin.WrapH(handler))
+}
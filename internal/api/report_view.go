@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"seed-detect/internal/crawl"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 采集报告的 html 页面，只做展示，字段与 json 一致
+
+type reportRow struct {
+	Key   string
+	Count int
+}
+
+// sortedRows 按次数从多到少排列，次数相同按键排列
+func sortedRows[K comparable](m map[K]int, key func(K) string) []reportRow {
+	rows := make([]reportRow, 0, len(m))
+	for k, v := range m {
+		rows = append(rows, reportRow{Key: key(k), Count: v})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
+
+// depthRows 深度按从浅到深排列
+func depthRows(m map[int]int) []reportRow {
+	depths := make([]int, 0, len(m))
+	for depth := range m {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	rows := make([]reportRow, 0, len(depths))
+	for _, depth := range depths {
+		rows = append(rows, reportRow{Key: strconv.Itoa(depth), Count: m[depth]})
+	}
+	return rows
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes": func(n int64) string {
+		const unit = 1024
+		if n < unit {
+			return fmt.Sprintf("%d B", n)
+		}
+		div, exp := int64(unit), 0
+		for m := n / unit; m >= unit; m /= unit {
+			div *= unit
+			exp++
+		}
+		return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+	},
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.DateTime)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>采集报告 {{.Report.TaskID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; min-width: 24em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f4f4f4; }
+td.n { text-align: right; }
+</style>
+</head>
+<body>
+{{with .Report}}
+<h1>采集报告</h1>
+<table>
+<tr><th>任务</th><td>{{.TaskID}}</td></tr>
+<tr><th>地址</th><td><a href="{{.URL}}">{{.URL}}</a></td></tr>
+<tr><th>状态</th><td>{{.Status}}</td></tr>
+<tr><th>开始时间</th><td>{{time .StartedAt}}</td></tr>
+<tr><th>结束时间</th><td>{{time .FinishedAt}}</td></tr>
+<tr><th>运行时长</th><td>{{printf "%.1f" .DurationSeconds}} 秒</td></tr>
+<tr><th>请求数</th><td class="n">{{.Requests}}</td></tr>
+<tr><th>成功</th><td class="n">{{.Pages}}</td></tr>
+<tr><th>失败</th><td class="n">{{.Errors}}</td></tr>
+<tr><th>下载量</th><td class="n">{{bytes .Bytes}}</td></tr>
+<tr><th>平均耗时</th><td class="n">{{printf "%.0f" .AvgLatencyMs}} ms</td></tr>
+<tr><th>正文数</th><td class="n">{{.Documents}}</td></tr>
+<tr><th>重复页面</th><td class="n">{{.Duplicates}}</td></tr>
+<tr><th>新增 / 变化 / 未变 / 未修改</th><td class="n">{{.Changes.New}} / {{.Changes.Changed}} / {{.Changes.Unchanged}} / {{.Changes.NotModified}}</td></tr>
+</table>
+{{end}}
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+{{if .Rows}}
+<table>
+<tr><th>{{.Key}}</th><th>次数</th></tr>
+{{range .Rows}}<tr><td>{{.Key}}</td><td class="n">{{.Count}}</td></tr>
+{{end}}
+</table>
+{{else}}
+<p>无</p>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+type reportSection struct {
+	Title string
+	Key   string
+	Rows  []reportRow
+}
+
+// renderReport 生成采集报告的 html 页面
+func renderReport(r *crawl.Report) ([]byte, error) {
+	errorRows := make([]reportRow, 0, len(r.TopErrors))
+	for _, e := range r.TopErrors {
+		errorRows = append(errorRows, reportRow{Key: e.Cause, Count: e.Count})
+	}
+	data := struct {
+		Report   *crawl.Report
+		Sections []reportSection
+	}{
+		Report: r,
+		Sections: []reportSection{
+			{Title: "状态码", Key: "状态码", Rows: sortedRows(r.StatusCodes, strconv.Itoa)},
+			{Title: "内容类型", Key: "类型", Rows: sortedRows(r.ContentTypes, func(s string) string { return s })},
+			{Title: "页面类型", Key: "类型", Rows: sortedRows(r.PageClasses, func(s string) string { return s })},
+			{Title: "深度分布", Key: "深度", Rows: depthRows(r.Depths)},
+			{Title: "主要错误", Key: "原因", Rows: errorRows},
+			{Title: "跳过的链接", Key: "原因", Rows: sortedRows(r.Skipped, func(s string) string { return s })},
+		},
+	}
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"seed-detect/internal/task"
+)
+
+type ScheduleHandler struct {
+	scheduler *task.Scheduler
+	logger    *zap.Logger
+}
+
+// ScheduleRequest 定时采集计划，cron（如 "0 * * * *"、"@weekly"）与 interval（如 "1h"）二选一
+type ScheduleRequest struct {
+	Name     string `json:"name"`
+	Cron     string `json:"cron"`
+	Interval string `json:"interval"`
+	// 每次运行创建的采集任务，与 /task/submit 的参数一致
+	Task TaskInfo `json:"task"`
+}
+
+func NewScheduleHandler(scheduler *task.Scheduler, logger *zap.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+func (h *ScheduleHandler) RegisterRouter(server *gin.Engine) {
+	group := server.Group("/schedules")
+	group.POST("", h.addSchedule)
+	group.GET("", h.listSchedules)
+	group.GET("/:id", h.getSchedule)
+	group.DELETE("/:id", h.removeSchedule)
+}
+
+func (h *ScheduleHandler) addSchedule(ctx *gin.Context) {
+	var req ScheduleRequest
+	if err := ctx.Bind(&req); err != nil || req.Task.Url == "" {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  "参数不合法",
+		})
+		return
+	}
+
+	opts, err := req.Task.options()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	schedule, err := h.scheduler.Add(req.Name, req.Task.Url, opts, req.Cron, req.Interval)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	h.logger.Info("定时计划已创建", zap.String("schedule", schedule.ID), zap.String("url", schedule.URL))
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: schedule,
+	})
+}
+
+func (h *ScheduleHandler) listSchedules(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: h.scheduler.List(),
+	})
+}
+
+func (h *ScheduleHandler) getSchedule(ctx *gin.Context) {
+	schedule, err := h.scheduler.Get(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, Result{
+			Code: InvalidScheduleId,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: schedule,
+	})
+}
+
+// removeSchedule 删除计划，已创建的任务不受影响
+func (h *ScheduleHandler) removeSchedule(ctx *gin.Context) {
+	if err := h.scheduler.Remove(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusNotFound, Result{
+			Code: InvalidScheduleId,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{})
+}
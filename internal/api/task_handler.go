@@ -1,26 +1,101 @@
 package api
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
 	"seed-detect/internal/crawl"
+	"seed-detect/internal/task"
 )
 
 type TaskHandler struct {
-	spider *crawl.Spider
-	logger *zap.Logger
+	manager *task.Manager
+	logger  *zap.Logger
 }
 
 type TaskInfo struct {
 	Url      string `json:"url"`
 	MaxDepth int    `json:"maxDepth"`
+	// 附件下载配置
+	Attachments crawl.AttachmentOptions `json:"attachments"`
+	// 列表页跟随翻页及单条分页链最大页数
+	FollowPagination bool `json:"followPagination"`
+	MaxPages         int  `json:"maxPages"`
+	// robots.txt 处理方式：ignore（默认）、respect、audit
+	Robots string `json:"robots"`
+	// 按站点限速，为 0 的项使用默认值
+	Politeness crawl.PolitenessOptions `json:"politeness"`
+	// 页数、字节数、时长预算，为 0 的项不限制
+	Budget crawl.Budget `json:"budget"`
+	// 采集顺序：bfs（默认）、dfs、best
+	Strategy string `json:"strategy"`
+	// 采集模式：full（默认）、incremental
+	Mode string `json:"mode"`
+	// 近似重复检测，默认开启，阈值为海明距离（默认 6，最大 10）
+	Dedup crawl.DedupOptions `json:"dedup"`
+	// 排队优先级：high、normal（默认）、low
+	Priority string `json:"priority"`
 }
 
-func NewTaskHandler(spider *crawl.Spider, logger *zap.Logger) *TaskHandler {
+// options 校验请求参数并转换为采集配置，提交任务和定时任务共用
+func (req *TaskInfo) options() (crawl.Options, error) {
+	robots, err := crawl.ParseRobotsMode(req.Robots)
+	if err != nil {
+		return crawl.Options{}, err
+	}
+	strategy, err := crawl.ParseStrategy(req.Strategy)
+	if err != nil {
+		return crawl.Options{}, err
+	}
+	mode, err := crawl.ParseCrawlMode(req.Mode)
+	if err != nil {
+		return crawl.Options{}, err
+	}
+
+	priority, err := crawl.ParsePriority(req.Priority)
+	if err != nil {
+		return crawl.Options{}, err
+	}
+	if err := req.Dedup.Validate(); err != nil {
+		return crawl.Options{}, err
+	}
+
+	maxDepth := req.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = 10
+	}
+	return crawl.Options{
+		MaxDepth:         maxDepth,
+		Attachments:      req.Attachments,
+		FollowPagination: req.FollowPagination,
+		MaxPages:         req.MaxPages,
+		Robots:           robots,
+		Politeness:       req.Politeness,
+		Budget:           req.Budget,
+		Strategy:         strategy,
+		Mode:             mode,
+		Dedup:            req.Dedup,
+		Priority:         priority,
+	}, nil
+}
+
+// OffsiteResponse 任务的站外链接及域名汇总
+type OffsiteResponse struct {
+	Domains []crawl.OffsiteDomain `json:"domains"`
+	Links   []crawl.OffsiteLink   `json:"links"`
+}
+
+// DuplicatesResponse 任务的近似重复页面
+type DuplicatesResponse struct {
+	Count    int                      `json:"count"`
+	Clusters []crawl.DuplicateCluster `json:"clusters"`
+}
+
+func NewTaskHandler(manager *task.Manager, logger *zap.Logger) *TaskHandler {
 	return &TaskHandler{
-		spider: spider,
-		logger: logger,
+		manager: manager,
+		logger:  logger,
 	}
 }
 
@@ -28,6 +103,16 @@ func (h *TaskHandler) RegisterRouter(server *gin.Engine) {
 	// 小必姐消息查询
 	xbj := server.Group("/task")
 	xbj.POST("/submit", h.submitTask)
+	xbj.GET("/:id", h.getTask)
+	xbj.GET("/:id/offsite", h.offsiteLinks)
+	xbj.GET("/:id/robots", h.robotsRecords)
+	xbj.GET("/:id/hosts", h.hostStats)
+	xbj.GET("/:id/duplicates", h.duplicates)
+	xbj.GET("/:id/report", h.report)
+	xbj.POST("/:id/cancel", h.cancelTask)
+	xbj.POST("/:id/resume", h.resumeTask)
+	server.GET("/tasks", h.listTasks)
+	server.GET("/queue", h.queueStats)
 }
 
 func (h *TaskHandler) submitTask(ctx *gin.Context) {
@@ -44,20 +129,177 @@ func (h *TaskHandler) submitTask(ctx *gin.Context) {
 		return
 	}
 
-	go func() {
-		maxDepth := req.MaxDepth
-		if maxDepth == 0 {
-			maxDepth = 10
-		}
-		// todo: 如果没有返回错误 则说明采集成功  记录数据库
-		err := h.spider.Start(req.Url, maxDepth)
-		if err != nil {
-			logger.Error(err.Error())
-		}
-	}()
+	opts, err := req.options()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	// todo: 任务结果记录数据库
+	t, err := h.manager.Submit(req.Url, opts)
+	if err != nil {
+		queueFull(ctx, err)
+		return
+	}
 
 	ctx.JSON(http.StatusOK, Result{
-		Data: req,
+		Data: t,
 	})
 	return
 }
+
+func (h *TaskHandler) getTask(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: t,
+	})
+}
+
+func (h *TaskHandler) listTasks(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: h.manager.List(),
+	})
+}
+
+// offsiteLinks 站外链接，?platform=wechat 只返回该平台的链接
+func (h *TaskHandler) offsiteLinks(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: OffsiteResponse{
+			Domains: t.Offsite.Domains(),
+			Links:   t.Offsite.Links(ctx.Query("platform")),
+		},
+	})
+}
+
+// robotsRecords robots.txt 禁止采集的地址（respect 模式下已跳过，audit 模式下照常采集）
+func (h *TaskHandler) robotsRecords(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: t.RobotsRecords(),
+	})
+}
+
+// hostStats 各站点的请求统计：平均耗时、错误率、当前间隔、暂停状态
+func (h *TaskHandler) hostStats(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: t.Hosts.Stats(),
+	})
+}
+
+// duplicates 近似重复的页面，按规范地址分组
+func (h *TaskHandler) duplicates(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: DuplicatesResponse{
+			Count:    t.Duplicates.Count(),
+			Clusters: t.Duplicates.Clusters(),
+		},
+	})
+}
+
+// report 采集报告，?format=html 或浏览器访问时返回 html 页面
+func (h *TaskHandler) report(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	report := t.Report()
+	format := ctx.Query("format")
+	if format == "" && ctx.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		format = "html"
+	}
+	if format != "html" {
+		ctx.JSON(http.StatusOK, Result{
+			Data: report,
+		})
+		return
+	}
+	page, err := renderReport(report)
+	if err != nil {
+		h.logger.Error("采集报告生成失败", zap.String("task", t.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
+
+// cancelTask 取消运行中的任务，之后可通过 resume 继续
+func (h *TaskHandler) cancelTask(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	if err := h.manager.Cancel(t.ID); err != nil {
+		ctx.JSON(http.StatusConflict, Result{
+			Code: InvalidTaskState,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: t,
+	})
+}
+
+// resumeTask 从检查点继续已取消或中断的任务
+func (h *TaskHandler) resumeTask(ctx *gin.Context) {
+	t, ok := h.task(ctx)
+	if !ok {
+		return
+	}
+	if _, err := h.manager.Resume(t.ID); err != nil {
+		if errors.Is(err, task.ErrQueueFull) {
+			queueFull(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusConflict, Result{
+			Code: InvalidTaskState,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: t,
+	})
+}
+
+// queueStats 运行及排队中的任务数
+func (h *TaskHandler) queueStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: h.manager.QueueStats(),
+	})
+}
+
+// task 按路径参数查找任务，不存在时直接返回错误
+func (h *TaskHandler) task(ctx *gin.Context) (*crawl.Task, bool) {
+	t, ok := h.manager.Get(ctx.Param("id"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, Result{
+			Code: InvalidTaskId,
+			Msg:  "任务不存在",
+		})
+	}
+	return t, ok
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"seed-detect/internal/crawl"
+)
+
+type TemplateHandler struct {
+	templates *crawl.TemplateStore
+	logger    *zap.Logger
+}
+
+// TemplateTestRequest url 与 html 二选一；template 为空时使用已加载的模板中与 url 匹配的一个
+type TemplateTestRequest struct {
+	Url      string          `json:"url"`
+	Html     string          `json:"html"`
+	Format   string          `json:"format"`
+	Template *crawl.Template `json:"template"`
+}
+
+func NewTemplateHandler(templates *crawl.TemplateStore, logger *zap.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templates: templates,
+		logger:    logger,
+	}
+}
+
+func (h *TemplateHandler) RegisterRouter(server *gin.Engine) {
+	group := server.Group("/templates")
+	group.GET("", h.list)
+	group.POST("/reload", h.reload)
+	group.POST("/test", h.test)
+}
+
+func (h *TemplateHandler) list(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Result{
+		Data: h.templates.Templates(),
+	})
+}
+
+func (h *TemplateHandler) reload(ctx *gin.Context) {
+	if err := h.templates.Load(); err != nil {
+		ctx.JSON(http.StatusOK, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: len(h.templates.Templates()),
+	})
+}
+
+func (h *TemplateHandler) test(ctx *gin.Context) {
+	logger := h.logger.Named("TemplateHandler test")
+	var req TemplateTestRequest
+
+	if err := ctx.Bind(&req); err != nil || (req.Url == "" && req.Html == "") {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  "参数不合法",
+		})
+		return
+	}
+
+	format, err := crawl.ParseOutputFormat(req.Format)
+	if err == nil && req.Template != nil {
+		err = req.Template.Compile()
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Result{
+			Code: InvalidBody,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	var doc *goquery.Document
+	if req.Html != "" {
+		doc, err = crawl.ParseDocument(req.Html, req.Url)
+	} else {
+		doc, err = crawl.FetchDocument(ctx.Request.Context(), req.Url)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		ctx.JSON(http.StatusOK, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	template := req.Template
+	if template == nil {
+		template = h.templates.Match(doc.Url)
+	}
+
+	extractor := crawl.NewContentExtractor()
+	extractor.Format = format
+	content, err := extractor.ExtractWithTemplate(doc, template)
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{
+			Code: SystemError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Result{
+		Data: content,
+	})
+}
@@ -1,5 +1,10 @@
 package api
 
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
 type Result struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
@@ -11,7 +16,13 @@ const (
 	BadRequest    = 400
 	InvalidBody   = 401
 	InvalidTaskId = 402
-	SystemError   = 500
+	// 任务当前状态不支持该操作
+	InvalidTaskState  = 403
+	InvalidScheduleId = 404
+	InvalidJobId      = 405
+	// 等待队列已满
+	QueueFull   = 406
+	SystemError = 500
 )
 
 const (
@@ -19,3 +30,11 @@ const (
 	UserFlag        = "user_message"
 	EmbeddingSearch = "product_search_embed"
 )
+
+// queueFull 等待队列已满时返回 429，客户端稍后重试
+func queueFull(ctx *gin.Context, err error) {
+	ctx.JSON(http.StatusTooManyRequests, Result{
+		Code: QueueFull,
+		Msg:  err.Error(),
+	})
+}
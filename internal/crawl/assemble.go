@@ -0,0 +1,552 @@
+package crawl
+
+import (
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentMode 正文拼装方式
+type ContentMode string
+
+const (
+	// ContentModeContainer 选出单个最佳容器节点，按文档顺序输出其中的段落
+	ContentModeContainer ContentMode = "container"
+	// ContentModeNodes 按密度排序拼接多个文本节点（嵌套节点会导致内容重复）
+	ContentModeNodes ContentMode = "nodes"
+)
+
+// BlockType 正文块类型
+type BlockType string
+
+const (
+	BlockHeading   BlockType = "heading"
+	BlockParagraph BlockType = "paragraph"
+	BlockListItem  BlockType = "list_item"
+	BlockTableRow  BlockType = "table_row"
+	BlockCode      BlockType = "code"
+	BlockQuote     BlockType = "quote"
+	BlockImage     BlockType = "image"
+)
+
+// Block 按文档顺序组织的正文块
+type Block struct {
+	Type  BlockType `json:"type"`
+	Text  string    `json:"text"`
+	Level int       `json:"level,omitempty"`
+	// 图片的绝对地址
+	Src string `json:"src,omitempty"`
+
+	markdown string
+}
+
+// OutputFormat 正文输出格式，Content 纯文本始终输出
+type OutputFormat string
+
+const (
+	OutputText     OutputFormat = "text"
+	OutputMarkdown OutputFormat = "markdown"
+	OutputBlocks   OutputFormat = "blocks"
+)
+
+// ParseOutputFormat 解析输出格式，空值为纯文本
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return OutputText, nil
+	case OutputText, OutputMarkdown, OutputBlocks:
+		return format, nil
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s", s)
+	}
+}
+
+// boilerplateSelector 与正文无关的元素
+const boilerplateSelector = "script, style, noscript, nav, header, footer, aside, .sidebar, .ad, .advertisement"
+
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "fieldset": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "tbody": true, "thead": true, "tfoot": true, "tr": true,
+	"td": true, "th": true, "ul": true,
+}
+
+var skipTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "select": true,
+	"button": true, "input": true, "textarea": true, "svg": true,
+}
+
+var punctuationRe = regexp.MustCompile(`[，。、；！？,;]`)
+
+// selectContainer 选出正文所在的最佳容器节点
+// 参考 readability：每个段落按长度和标点给父节点加分、祖父节点加一半分，最后按链接密度衰减
+func (ce *ContentExtractor) selectContainer(doc *goquery.Document) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var candidates []*goquery.Selection
+
+	doc.Find("p, pre, blockquote, td, div, section, li").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) != "p" && goquery.NodeName(s) != "pre" && hasBlockDescendant(s) {
+			return
+		}
+		if s.Closest(boilerplateSelector).Length() > 0 {
+			return
+		}
+
+		text := ce.cleanText(s.Text())
+		runes := utf8.RuneCountInString(text)
+		if runes < 10 {
+			return
+		}
+
+		score := 1 + float64(len(punctuationRe.FindAllString(text, -1))) + math.Min(float64(runes)/100, 3)
+		score *= 1 - ce.calculateLinkDensity(s)
+
+		parent := s.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		for level, ancestor := range []*goquery.Selection{parent, parent.Parent()} {
+			if ancestor.Length() == 0 {
+				continue
+			}
+			node := ancestor.Get(0)
+			if _, ok := scores[node]; !ok {
+				candidates = append(candidates, ancestor)
+			}
+			scores[node] += score / float64(level+1)
+		}
+	})
+
+	var best *goquery.Selection
+	var bestScore float64
+	for _, candidate := range candidates {
+		score := scores[candidate.Get(0)] * (1 - ce.calculateLinkDensity(candidate))
+		if best == nil || score > bestScore {
+			best = candidate
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return doc.Find("body").First()
+	}
+	return best
+}
+
+func hasBlockDescendant(s *goquery.Selection) bool {
+	found := false
+	s.Find("*").EachWithBreak(func(i int, child *goquery.Selection) bool {
+		found = blockTags[goquery.NodeName(child)]
+		return !found
+	})
+	return found
+}
+
+// assembleBlocks 按文档顺序输出容器中的正文块，链接和图片地址基于 base 转为绝对地址
+func (ce *ContentExtractor) assembleBlocks(container *goquery.Selection, base *url.URL) []Block {
+	a := &blockAssembler{ce: ce, base: base}
+	for _, node := range container.Nodes {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			a.walk(child)
+		}
+	}
+	a.flush()
+	return a.blocks
+}
+
+type blockAssembler struct {
+	ce     *ContentExtractor
+	base   *url.URL
+	blocks []Block
+	// 当前段落的行内文本及对应的 Markdown
+	text     strings.Builder
+	markdown strings.Builder
+}
+
+func (a *blockAssembler) add(block Block) {
+	block.Text = a.ce.cleanText(block.Text)
+	block.markdown = a.ce.cleanText(block.markdown)
+	if block.Text == "" && block.Src == "" {
+		return
+	}
+	a.blocks = append(a.blocks, block)
+}
+
+// flush 将累积的行内文本输出为段落
+func (a *blockAssembler) flush() {
+	if a.text.Len() == 0 && a.markdown.Len() == 0 {
+		return
+	}
+	a.add(Block{Type: BlockParagraph, Text: a.text.String(), markdown: a.markdown.String()})
+	a.text.Reset()
+	a.markdown.Reset()
+}
+
+func (a *blockAssembler) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		a.text.WriteString(n.Data)
+		a.markdown.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	tag := n.Data
+	if skipTags[tag] {
+		return
+	}
+
+	switch tag {
+	case "br":
+		a.flush()
+	case "a":
+		text, markdown := a.inline(n)
+		a.text.WriteString(text)
+		a.markdown.WriteString(markdown)
+	case "img":
+		a.flush()
+		if src := a.resolve(firstAttrValue(n, "src", "data-src", "data-original")); src != "" {
+			alt := attrValue(n, "alt")
+			a.add(Block{Type: BlockImage, Text: alt, Src: src, markdown: fmt.Sprintf("![%s](%s)", alt, src)})
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		a.flush()
+		level := int(tag[1] - '0')
+		text, markdown := a.inline(n)
+		a.add(Block{Type: BlockHeading, Text: text, Level: level, markdown: strings.Repeat("#", level) + " " + markdown})
+	case "p":
+		a.flush()
+		a.walkChildren(n)
+		a.flush()
+	case "pre":
+		a.flush()
+		// 代码块保留原始换行
+		if text := strings.TrimSpace(nodeText(n)); text != "" {
+			a.blocks = append(a.blocks, Block{Type: BlockCode, Text: text, markdown: "```\n" + text + "\n```"})
+		}
+	case "blockquote":
+		a.flush()
+		text, markdown := a.inline(n)
+		a.add(Block{Type: BlockQuote, Text: text, markdown: "> " + markdown})
+	case "ul", "ol":
+		a.flush()
+		a.walkList(n, 1)
+	case "table":
+		a.flush()
+		a.walkTable(n)
+	default:
+		if blockTags[tag] {
+			a.flush()
+			a.walkChildren(n)
+			a.flush()
+			return
+		}
+		a.walkChildren(n)
+	}
+}
+
+func (a *blockAssembler) walkChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		a.walk(child)
+	}
+}
+
+// inline 节点下的行内文本及 Markdown（保留链接和图片）
+func (a *blockAssembler) inline(n *html.Node) (string, string) {
+	switch n.Type {
+	case html.TextNode:
+		return n.Data, n.Data
+	case html.ElementNode:
+	default:
+		return "", ""
+	}
+
+	switch n.Data {
+	case "br":
+		return " ", " "
+	case "img":
+		src := a.resolve(firstAttrValue(n, "src", "data-src", "data-original"))
+		if src == "" {
+			return "", ""
+		}
+		return "", fmt.Sprintf("![%s](%s)", attrValue(n, "alt"), src)
+	}
+	if skipTags[n.Data] {
+		return "", ""
+	}
+
+	var text, markdown strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		t, m := a.inline(child)
+		text.WriteString(t)
+		markdown.WriteString(m)
+	}
+
+	if n.Data == "a" {
+		href := a.resolve(attrValue(n, "href"))
+		label := strings.TrimSpace(markdown.String())
+		if href != "" && label != "" {
+			return text.String(), fmt.Sprintf("[%s](%s)", label, href)
+		}
+	}
+	return text.String(), markdown.String()
+}
+
+func (a *blockAssembler) resolve(ref string) string {
+	return absoluteURL(a.base, ref)
+}
+
+// walkList 列表项单独成块，嵌套列表层级递增
+func (a *blockAssembler) walkList(list *html.Node, level int) {
+	index := 0
+	for li := list.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+		index++
+
+		var text, markdown strings.Builder
+		var nested []*html.Node
+		for child := li.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && (child.Data == "ul" || child.Data == "ol") {
+				nested = append(nested, child)
+				continue
+			}
+			t, m := a.inline(child)
+			text.WriteString(t)
+			markdown.WriteString(m)
+		}
+
+		marker := "- "
+		if list.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", index)
+		}
+		a.add(Block{
+			Type:     BlockListItem,
+			Text:     text.String(),
+			Level:    level,
+			markdown: strings.Repeat("  ", level-1) + marker + a.ce.cleanText(markdown.String()),
+		})
+		for _, sub := range nested {
+			a.walkList(sub, level+1)
+		}
+	}
+}
+
+// walkTable 表格按行输出，单元格以 " | " 分隔，Markdown 以首行作为表头
+func (a *blockAssembler) walkTable(table *html.Node) {
+	first := true
+	var walkRows func(n *html.Node)
+	walkRows = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				var cells, markdownCells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						t, m := a.inline(cell)
+						cells = append(cells, a.ce.cleanText(t))
+						markdownCells = append(markdownCells, strings.ReplaceAll(a.ce.cleanText(m), "|", "\\|"))
+					}
+				}
+				text := strings.Join(cells, " | ")
+				if strings.Trim(text, " |") == "" {
+					continue
+				}
+
+				markdown := "| " + strings.Join(markdownCells, " | ") + " |"
+				if first {
+					markdown += "\n|" + strings.Repeat(" --- |", len(cells))
+					first = false
+				}
+				// 表头分隔行包含换行，不经过 add 清理
+				a.blocks = append(a.blocks, Block{Type: BlockTableRow, Text: text, markdown: markdown})
+			case "thead", "tbody", "tfoot":
+				walkRows(child)
+			}
+		}
+	}
+	walkRows(table)
+}
+
+// nodeText 节点下的全部文本（跳过脚本等）
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if skipTags[n.Data] {
+				return
+			}
+			if n.Data == "br" {
+				b.WriteString("\n")
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// blocksText 将正文块拼接为纯文本
+func blocksText(blocks []Block) string {
+	return joinBlocks(blocks, func(block Block) string {
+		if block.Type == BlockImage {
+			return ""
+		}
+		return block.Text
+	})
+}
+
+// blocksMarkdown 将正文块拼接为 Markdown
+func blocksMarkdown(blocks []Block) string {
+	return joinBlocks(blocks, func(block Block) string {
+		return block.markdown
+	})
+}
+
+// joinBlocks 段落之间空行分隔，连续的列表项、表格行逐行输出
+func joinBlocks(blocks []Block, render func(Block) string) string {
+	var b strings.Builder
+	var prev BlockType
+	for _, block := range blocks {
+		text := render(block)
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			if block.Type == prev && (block.Type == BlockListItem || block.Type == BlockTableRow) {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(text)
+		prev = block.Type
+	}
+	return b.String()
+}
+
+// documentBaseURL 页面的基准地址，优先使用 <base href>
+func documentBaseURL(doc *goquery.Document) *url.URL {
+	base := doc.Url
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if u, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			if base != nil {
+				return base.ResolveReference(u)
+			}
+			if u.IsAbs() {
+				return u
+			}
+		}
+	}
+	return base
+}
+
+// absoluteURL 转为绝对地址，忽略空链接、锚点和脚本链接
+func absoluteURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	lower := strings.ToLower(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "data:") {
+		return ""
+	}
+	return resolveURL(base, ref)
+}
+
+// resolveURL 基于 base 解析相对地址，base 为空时原样返回
+func resolveURL(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base == nil {
+		return u.String()
+	}
+	return base.ResolveReference(u).String()
+}
+
+var cssIdentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// nodePath 生成定位节点的 CSS 选择器，遇到带 id 的祖先节点即停止
+func nodePath(s *goquery.Selection) string {
+	if s.Length() == 0 {
+		return ""
+	}
+
+	var parts []string
+	for n := s.Get(0); n != nil && n.Type == html.ElementNode; n = n.Parent {
+		part := n.Data
+		if id := attrValue(n, "id"); cssIdentRe.MatchString(id) {
+			parts = append(parts, part+"#"+id)
+			break
+		}
+		for _, class := range strings.Fields(attrValue(n, "class")) {
+			if cssIdentRe.MatchString(class) {
+				part += "." + class
+			}
+		}
+		if index, total := typeIndex(n); total > 1 {
+			part += fmt.Sprintf(":nth-of-type(%d)", index)
+		}
+		parts = append(parts, part)
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+func firstAttrValue(n *html.Node, keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(attrValue(n, key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// typeIndex 节点在同类型兄弟节点中的序号（从 1 开始）及同类型兄弟节点总数
+func typeIndex(n *html.Node) (int, int) {
+	if n.Parent == nil {
+		return 1, 1
+	}
+	index, total := 0, 0
+	for sibling := n.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == n.Data {
+			total++
+			if sibling == n {
+				index = total
+			}
+		}
+	}
+	return index, total
+}
@@ -0,0 +1,83 @@
+package crawl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractContainer(t *testing.T) {
+	htmlContent := `<html><head><title>学院新闻</title></head><body>
+	<div class="nav"><a href="/">首页</a><a href="/news">新闻</a><a href="/about">关于</a></div>
+	<div id="wrapper">
+		<div class="article">
+			<h2>学院召开年度工作会议</h2>
+			<div class="content">
+				<p>会议由院长主持，全体教职工参加，会议总结了过去一年的工作，部署了新一年的重点任务。</p>
+				<p>会议指出，要坚持立德树人，持续推进学科建设，进一步提高人才培养质量。</p>
+				<ul><li>加强师资队伍建设</li><li>深化教学改革</li></ul>
+				<table><tr><td>时间</td><td>地点</td></tr><tr><td>上午九点</td><td>报告厅</td></tr></table>
+			</div>
+		</div>
+	</div>
+	<div class="footer">版权所有</div>
+	</body></html>`
+
+	content, err := NewContentExtractor().ExtractFromHTML(htmlContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(content.Content, "会议由院长主持"); n != 1 {
+		t.Fatalf("paragraph repeated %d times:\n%s", n, content.Content)
+	}
+	if content.NodePath != "div#wrapper > div.article > div.content" {
+		t.Fatalf("unexpected node path: %s", content.NodePath)
+	}
+
+	expected := strings.Join([]string{
+		"会议由院长主持，全体教职工参加，会议总结了过去一年的工作，部署了新一年的重点任务。",
+		"会议指出，要坚持立德树人，持续推进学科建设，进一步提高人才培养质量。",
+		"加强师资队伍建设\n深化教学改革",
+		"时间 | 地点\n上午九点 | 报告厅",
+	}, "\n\n")
+	if content.Content != expected {
+		t.Fatalf("unexpected content:\n%s", content.Content)
+	}
+}
+
+func TestExtractMarkdown(t *testing.T) {
+	htmlContent := `<html><body><div class="content">
+		<h2>招生简章</h2>
+		<p>详见<a href="/zs/2024.htm">招生计划</a>，报名截止时间为六月三十日，逾期不予受理。</p>
+		<p><img src="img/poster.jpg" alt="海报"></p>
+		<ol><li>填写报名表</li><li>提交材料</li></ol>
+		<table><tr><th>专业</th><th>人数</th></tr><tr><td>计算机</td><td>30</td></tr></table>
+	</div></body></html>`
+
+	extractor := NewContentExtractor()
+	extractor.Format = OutputMarkdown
+	content, err := extractor.ExtractFromHTMLWithURL(htmlContent, "https://www.example.edu.cn/news/index.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"## 招生简章",
+		"详见[招生计划](https://www.example.edu.cn/zs/2024.htm)，报名截止时间为六月三十日，逾期不予受理。",
+		"![海报](https://www.example.edu.cn/news/img/poster.jpg)",
+		"1. 填写报名表\n2. 提交材料",
+		"| 专业 | 人数 |\n| --- | --- |\n| 计算机 | 30 |",
+	}, "\n\n")
+	if content.Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", content.Markdown)
+	}
+
+	extractor.Format = OutputBlocks
+	content, err = extractor.ExtractFromHTMLWithURL(htmlContent, "https://www.example.edu.cn/news/index.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content.Blocks) != 7 || content.Blocks[2].Src != "https://www.example.edu.cn/news/img/poster.jpg" {
+		t.Fatalf("unexpected blocks: %+v", content.Blocks)
+	}
+}
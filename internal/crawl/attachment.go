@@ -0,0 +1,197 @@
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultAttachmentMaxSize 单个附件默认大小上限
+const DefaultAttachmentMaxSize int64 = 20 << 20
+
+var ErrAttachmentTooLarge = errors.New("附件超过大小限制")
+
+// AttachmentOptions 采集任务的附件下载配置
+type AttachmentOptions struct {
+	// 是否将正文中的附件加入下载队列
+	Enabled bool `json:"enabled"`
+	// 单个附件大小上限（字节），0 使用默认值
+	MaxSize int64 `json:"maxSize"`
+	// 允许下载的文件类型，为空则不限制
+	Types []string `json:"types"`
+}
+
+func (o AttachmentOptions) allow(fileType string) bool {
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, t := range o.Types {
+		if t == fileType {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachmentSink 附件存储
+type AttachmentSink interface {
+	Save(att Attachment, referer string, body io.Reader) error
+}
+
+// DirAttachmentSink 将附件保存到本地目录：<dir>/<host>/<md5(url)>.<type>
+type DirAttachmentSink struct {
+	dir string
+}
+
+func NewDirAttachmentSink(dir string) *DirAttachmentSink {
+	return &DirAttachmentSink{dir: dir}
+}
+
+func (s *DirAttachmentSink) Save(att Attachment, referer string, body io.Reader) error {
+	u, err := url.Parse(att.URL)
+	if err != nil {
+		return err
+	}
+
+	name := genMD5(att.URL)
+	if att.FileType != "" {
+		name += "." + att.FileType
+	}
+	dir := filepath.Join(s.dir, u.Hostname())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, body)
+	return err
+}
+
+type attachmentJob struct {
+	att     Attachment
+	referer string
+	opts    AttachmentOptions
+}
+
+// AttachmentDownloader 附件下载队列，与页面采集分开限流
+type AttachmentDownloader struct {
+	sink    AttachmentSink
+	client  *http.Client
+	jobs    chan attachmentJob
+	metrics Metrics
+	logger  *zap.Logger
+}
+
+func NewAttachmentDownloader(ctx context.Context, sink AttachmentSink, metrics Metrics, logger *zap.Logger) *AttachmentDownloader {
+	d := &AttachmentDownloader{
+		sink:    sink,
+		client:  &http.Client{Timeout: 60 * time.Second},
+		jobs:    make(chan attachmentJob, 1000),
+		metrics: orNop(metrics),
+		logger:  logger.Named("AttachmentDownloader"),
+	}
+
+	for i := 0; i < 4; i++ {
+		go d.work(ctx)
+	}
+	return d
+}
+
+// Enqueue 加入下载队列，队列已满时丢弃
+func (d *AttachmentDownloader) Enqueue(att Attachment, referer string, opts AttachmentOptions) bool {
+	// 类型未知的附件在下载时根据 Content-Type 再判断
+	if att.FileType != "" && !opts.allow(att.FileType) {
+		return false
+	}
+	select {
+	case d.jobs <- attachmentJob{att: att, referer: referer, opts: opts}:
+		return true
+	default:
+		d.logger.Warn(fmt.Sprintf("⚠️ 附件队列已满，丢弃: %s", att.URL))
+		return false
+	}
+}
+
+func (d *AttachmentDownloader) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-d.jobs:
+			if err := d.download(ctx, job); err != nil {
+				d.logger.Error(fmt.Sprintf("附件下载失败: %s", job.att.URL), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (d *AttachmentDownloader) download(ctx context.Context, job attachmentJob) error {
+	maxSize := job.opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultAttachmentMaxSize
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, job.att.URL, nil)
+	if err != nil {
+		return err
+	}
+	if job.referer != "" {
+		req.Header.Set("Referer", job.referer)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return ErrAttachmentTooLarge
+	}
+
+	att := job.att
+	if att.FileType == "" {
+		contentType := resp.Header.Get("Content-Type")
+		// 按链接形式猜测的下载链接，实际返回网页时不是附件
+		if strings.Contains(strings.ToLower(contentType), "html") {
+			d.logger.Info(fmt.Sprintf("🟡 返回网页，不是附件: %s", att.URL))
+			return nil
+		}
+		att.FileType = InferFileType(att.URL, contentType)
+	}
+	if !job.opts.allow(att.FileType) {
+		return nil
+	}
+
+	// 先读入内存，超过上限则放弃，避免写入不完整的文件
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > maxSize {
+		return ErrAttachmentTooLarge
+	}
+
+	if err := d.sink.Save(att, job.referer, bytes.NewReader(body)); err != nil {
+		d.metrics.SinkError(SinkAttachment)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 采集预算
+// 限制任务的总页数、单站点页数、下载总字节数和运行时长，任一预算用尽后不再发出新请求，
+// 已发出的请求处理完后任务以 budget_exhausted 结束，并记录用尽的预算。
+// 单个响应超过 MaxResponseBytes 时截断，SkipOversize 为 true 时跳过该页面
+
+const (
+	BudgetPages        = "pages"
+	BudgetPagesPerHost = "pages_per_host"
+	BudgetBytes        = "bytes"
+	BudgetDuration     = "duration"
+)
+
+// Budget 任务预算，为 0 的项不限制
+type Budget struct {
+	// 总页数
+	Pages int `json:"pages"`
+	// 单站点页数
+	PagesPerHost int `json:"pagesPerHost"`
+	// 下载总字节数
+	Bytes int64 `json:"bytes"`
+	// 单个响应最大字节数
+	MaxResponseBytes int `json:"maxResponseBytes"`
+	// 响应超过 MaxResponseBytes 时跳过，默认截断后照常处理
+	SkipOversize bool `json:"skipOversize"`
+	// 运行时长（秒）
+	DurationSeconds int `json:"durationSeconds"`
+}
+
+// BudgetError 预算用尽
+type BudgetError struct {
+	Budgets []string
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("采集预算已用尽: %s", strings.Join(e.Budgets, ", "))
+}
+
+// budgetTracker 任务内的预算消耗，并发安全
+type budgetTracker struct {
+	budget Budget
+	// 运行时长到期后取消
+	ctx context.Context
+
+	mu        sync.Mutex
+	pages     int
+	hostPages map[string]int
+	bytes     int64
+	// 用尽的预算，按发生顺序
+	hit []string
+}
+
+func newBudgetTracker(ctx context.Context, budget Budget) *budgetTracker {
+	return &budgetTracker{
+		budget:    budget,
+		ctx:       ctx,
+		hostPages: map[string]int{},
+	}
+}
+
+// allow 为即将发出的请求占用页数预算，预算用尽时返回 false
+func (b *budgetTracker) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.ctx.Err() != nil:
+		// 任务被取消时不计为预算用尽
+		if errors.Is(b.ctx.Err(), context.DeadlineExceeded) {
+			b.exhaust(BudgetDuration)
+		}
+	case b.budget.Bytes > 0 && b.bytes >= b.budget.Bytes:
+		b.exhaust(BudgetBytes)
+	case b.budget.Pages > 0 && b.pages >= b.budget.Pages:
+		b.exhaust(BudgetPages)
+	case b.budget.PagesPerHost > 0 && b.hostPages[host] >= b.budget.PagesPerHost:
+		b.exhaust(BudgetPagesPerHost)
+	default:
+		b.pages++
+		b.hostPages[host]++
+		return true
+	}
+	return false
+}
+
+// addBytes 累计下载字节数
+func (b *budgetTracker) addBytes(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bytes += int64(n)
+}
+
+// cancelled 请求在等待期间被取消，运行时长到期时记为预算用尽
+func (b *budgetTracker) cancelled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if errors.Is(b.ctx.Err(), context.DeadlineExceeded) {
+		b.exhaust(BudgetDuration)
+	}
+}
+
+func (b *budgetTracker) exhaust(budget string) {
+	for _, hit := range b.hit {
+		if hit == budget {
+			return
+		}
+	}
+	b.hit = append(b.hit, budget)
+}
+
+// err 有预算用尽时返回 BudgetError
+func (b *budgetTracker) err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.hit) == 0 {
+		return nil
+	}
+	return &BudgetError{Budgets: append([]string(nil), b.hit...)}
+}
+
+// oversize 响应是否达到单个响应的字节上限（colly 已按上限截断）
+func (b *budgetTracker) oversize(size int) bool {
+	return b.budget.MaxResponseBytes > 0 && size >= b.budget.MaxResponseBytes
+}
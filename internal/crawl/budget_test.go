@@ -0,0 +1,49 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudgetTracker(t *testing.T) {
+	b := newBudgetTracker(context.Background(), Budget{Pages: 3, PagesPerHost: 2, Bytes: 100})
+	if !b.allow("a.example.gov.cn") || !b.allow("a.example.gov.cn") || b.allow("a.example.gov.cn") {
+		t.Fatal("pages per host")
+	}
+	if !b.allow("b.example.gov.cn") || b.allow("c.example.gov.cn") {
+		t.Fatal("total pages")
+	}
+	var budgetErr *BudgetError
+	if err := b.err(); !errors.As(err, &budgetErr) || len(budgetErr.Budgets) != 2 ||
+		budgetErr.Budgets[0] != BudgetPagesPerHost || budgetErr.Budgets[1] != BudgetPages {
+		t.Errorf("err = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	b = newBudgetTracker(ctx, Budget{Bytes: 100})
+	b.addBytes(120)
+	if b.allow("a.example.gov.cn") {
+		t.Error("bytes budget")
+	}
+	<-ctx.Done()
+	b.allow("a.example.gov.cn")
+	if err := b.err(); err == nil || err.Error() != "采集预算已用尽: bytes, duration" {
+		t.Errorf("err = %v", err)
+	}
+
+	// 取消任务不计为预算用尽
+	cancelled, cancelTask := context.WithCancel(context.Background())
+	cancelTask()
+	if c := newBudgetTracker(cancelled, Budget{}); c.allow("a.example.gov.cn") || c.err() != nil {
+		t.Error("cancelled task")
+	}
+
+	task := NewTask("https://www.example.gov.cn/", Options{})
+	task.Finish(b.err())
+	if task.GetStatus() != TaskBudgetExhausted || len(task.BudgetHit) != 2 {
+		t.Errorf("task status = %s, budgets = %v", task.GetStatus(), task.BudgetHit)
+	}
+}
@@ -0,0 +1,114 @@
+package crawl
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 采集检查点
+// 运行中的任务定期把待采集队列（含进行中的请求）、已发现地址、翻页计数、正文指纹索引和站外链接写入磁盘，
+// 取消或进程退出时再写一次。任务正常结束后删除检查点；
+// 进程重启后状态仍为 running 的检查点视为中断，可从检查点继续采集
+
+// DefaultCheckpointInterval 定期写检查点的间隔
+const DefaultCheckpointInterval = 30 * time.Second
+
+// Checkpoint 任务检查点
+type Checkpoint struct {
+	TaskID    string     `json:"task_id"`
+	URL       string     `json:"url"`
+	Options   Options    `json:"options"`
+	Status    TaskStatus `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	SavedAt   time.Time  `json:"saved_at"`
+	// 待采集地址
+	Pending []FrontierItem `json:"pending"`
+	// 已发现的地址（含待采集）
+	Seen []string `json:"seen"`
+	// 经过翻页到达各页面的次数
+	PageHops map[string]int `json:"page_hops,omitempty"`
+	// best 策略各 URL 模式已入队的次数
+	Patterns map[string]int `json:"patterns,omitempty"`
+	// 正文指纹索引，继续采集时仍能识别与中断前页面重复的正文
+	Duplicates []DuplicateCluster `json:"duplicates,omitempty"`
+	// 站外链接记录
+	Offsite *OffsiteState `json:"offsite,omitempty"`
+}
+
+// CheckpointStore 检查点目录，每个任务一个 json 文件
+type CheckpointStore struct {
+	dir string
+}
+
+func NewCheckpointStore(dir string) (*CheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &CheckpointStore{dir: dir}, nil
+}
+
+func (s *CheckpointStore) path(taskID string) string {
+	return filepath.Join(s.dir, taskID+".json")
+}
+
+// Save 先写临时文件再替换，避免写到一半时进程退出留下损坏的检查点
+func (s *CheckpointStore) Save(cp *Checkpoint) error {
+	cp.SavedAt = time.Now()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := s.path(cp.TaskID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(cp.TaskID))
+}
+
+// Load 读取任务的检查点，不存在时返回 os.ErrNotExist
+func (s *CheckpointStore) Load(taskID string) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.path(taskID))
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// List 按任务创建时间返回所有检查点，无法解析的文件跳过
+func (s *CheckpointStore) List() ([]*Checkpoint, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []*Checkpoint
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if cp, err := s.Load(strings.TrimSuffix(name, ".json")); err == nil {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+	return checkpoints, nil
+}
+
+// Delete 删除任务的检查点，不存在时忽略
+func (s *CheckpointStore) Delete(taskID string) error {
+	if err := os.Remove(s.path(taskID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package crawl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	store, err := NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFrontier(StrategyBest)
+	f.Push(FrontierItem{URL: "https://www.example.gov.cn/", Depth: 1})
+	f.Push(FrontierItem{URL: "https://www.example.gov.cn/col/col12/index.html", Depth: 2, Anchor: "通知公告"})
+	f.Push(FrontierItem{URL: "https://www.example.gov.cn/art/2024/6/1/art_1_101.html", Depth: 2})
+	visited, _ := f.Pop()
+
+	task := NewTask("https://www.example.gov.cn/", Options{MaxDepth: 5, Strategy: StrategyBest, Robots: RobotsRespect})
+	base := uint64(0xF0F0_1234_5678_9ABC)
+	task.Duplicates.Check("https://www.example.gov.cn/art/1.html", base)
+	task.Duplicates.Check("https://www.example.gov.cn/col/art/1.html", base^1)
+	task.Duplicates.Check("https://www.example.gov.cn/art/2.html", ^base)
+	task.Offsite.Record("https://www.example.gov.cn/", "https://mp.weixin.qq.com/s/abc", "公众号")
+	task.Offsite.Record("https://www.example.gov.cn/col/", "https://mp.weixin.qq.com/s/abc", "公众号")
+	pending, seen, patterns := f.Snapshot()
+	err = store.Save(&Checkpoint{
+		TaskID:     task.ID,
+		URL:        task.URL,
+		Options:    task.Options,
+		Status:     TaskRunning,
+		CreatedAt:  task.CreatedAt,
+		Pending:    pending,
+		Seen:       seen,
+		PageHops:   map[string]int{"https://www.example.gov.cn/col/col12/index_2.html": 1},
+		Patterns:   patterns,
+		Duplicates: task.Duplicates.State(),
+		Offsite:    task.Offsite.State(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoints, err := store.List()
+	if err != nil || len(checkpoints) != 1 {
+		t.Fatalf("list = %v, %v", checkpoints, err)
+	}
+	cp := checkpoints[0]
+	restored := RestoreTask(cp)
+	if restored.ID != task.ID || restored.GetStatus() != TaskInterrupted || !restored.Resumable() ||
+		restored.Options.Strategy != StrategyBest || restored.Options.Robots != RobotsRespect {
+		t.Errorf("restored task = %+v", restored)
+	}
+	checkRestored := func(name string, restored *Task) {
+		t.Helper()
+		if restored.Duplicates.Count() != 1 || len(restored.Duplicates.Clusters()) != 1 {
+			t.Errorf("%s: duplicates = %+v", name, restored.Duplicates.Clusters())
+		}
+		// 与中断前的规范地址重复的新页面仍能识别
+		if canonical, _, dup := restored.Duplicates.Check("https://www.example.gov.cn/col/art/2.html", ^base^2); !dup || canonical != "https://www.example.gov.cn/art/2.html" {
+			t.Errorf("%s: check after restore = %s, %v", name, canonical, dup)
+		}
+		links, domains := restored.Offsite.Links(""), restored.Offsite.Domains()
+		if len(links) != 1 || links[0].Count != 2 || len(domains) != 1 || domains[0].Count != 2 || domains[0].Platform != "wechat" {
+			t.Errorf("%s: offsite = %+v, %+v", name, links, domains)
+		}
+	}
+	checkRestored("checkpoint", restored)
+
+	// 任务记录经 JSON 保存后同样保留指纹索引和站外链接
+	data, err := json.Marshal(task.Record())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record TaskRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	checkRestored("record", LoadTask(&record))
+
+	// 恢复后已采集的地址不再入队，待采集地址保持原顺序
+	g := NewFrontier(StrategyBest)
+	g.Restore(cp.Pending, cp.Seen, cp.Patterns)
+	if g.Push(visited) || g.Len() != 2 {
+		t.Errorf("restored frontier len = %d", g.Len())
+	}
+	want, _ := f.Pop()
+	if got, _ := g.Pop(); got.URL != want.URL || got.Priority != want.Priority {
+		t.Errorf("restored pop = %+v, want %+v", got, want)
+	}
+	// 同一 URL 模式的新地址得分与中断前一致
+	next := FrontierItem{URL: "https://www.example.gov.cn/art/2024/6/2/art_1_102.html", Depth: 2}
+	f.Push(next)
+	g.Push(next)
+	for f.Len() > 0 {
+		a, _ := f.Pop()
+		if b, _ := g.Pop(); a != b {
+			t.Errorf("restored pop = %+v, want %+v", b, a)
+		}
+	}
+
+	if err := store.Delete(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints, _ := store.List(); len(checkpoints) != 0 {
+		t.Errorf("checkpoints after delete = %d", len(checkpoints))
+	}
+}
@@ -0,0 +1,293 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 站点目录采集
+// 从政府网站导航、医院名录、高校名录等目录页中收集成员站点首页（名称、地址、省份、类别），
+// 沿分页翻完整个目录，校验首页可访问后作为新的采集任务。
+// 目录页命中带 directory 规则的站点模板时按模板抽取，否则使用启发式规则：
+// 指向其他站点、路径不超过一级的链接视为成员站点，行内其余文本和所在分组的标题用于识别省份和类别
+
+// Sector 站点所属行业
+const (
+	SectorGov        = "gov"
+	SectorHospital   = "hospital"
+	SectorUniversity = "university"
+)
+
+// DefaultHarvestPages 目录默认最多翻页数
+const DefaultHarvestPages = 20
+
+var provinces = []string{
+	"北京", "天津", "河北", "山西", "内蒙古", "辽宁", "吉林", "黑龙江", "上海", "江苏", "浙江",
+	"安徽", "福建", "江西", "山东", "河南", "湖北", "湖南", "广东", "广西", "海南", "重庆",
+	"四川", "贵州", "云南", "西藏", "陕西", "甘肃", "青海", "宁夏", "新疆", "香港", "澳门", "台湾",
+}
+
+// 分组标题的候选选择器
+const groupHeadingSelector = "h1, h2, h3, h4, h5, h6, dt, caption, .title, .tit"
+
+// DirectoryEntry 目录中的成员站点
+type DirectoryEntry struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Province string `json:"province,omitempty"`
+	Category string `json:"category,omitempty"`
+	Sector   string `json:"sector,omitempty"`
+	// 发现该站点的目录页
+	Source string `json:"source"`
+	// 校验结果：首页可访问时 Resolved 为 true，FinalURL 为跳转后的地址
+	Resolved bool   `json:"resolved"`
+	FinalURL string `json:"final_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HarvestOptions 目录采集参数
+type HarvestOptions struct {
+	// 最多翻页数，为 0 时使用 DefaultHarvestPages
+	MaxPages int `json:"maxPages"`
+	// 是否校验首页可访问
+	Validate bool `json:"validate"`
+	// 校验并发数，默认 8
+	Concurrency int `json:"concurrency"`
+}
+
+// ExtractDirectory 抽取目录页中的成员站点，按地址去重
+func (ce *ContentExtractor) ExtractDirectory(doc *goquery.Document) []DirectoryEntry {
+	var entries []DirectoryEntry
+	if t := ce.Templates.Match(doc.Url); t != nil && t.Directory != nil {
+		entries = ce.templateDirectory(doc, t.Directory)
+	} else {
+		entries = ce.heuristicDirectory(doc)
+	}
+
+	pageProvince := findProvince(doc.Find("title").Text())
+	source := ""
+	if doc.Url != nil {
+		source = doc.Url.String()
+	}
+
+	seen := map[string]bool{}
+	result := entries[:0]
+	for _, entry := range entries {
+		if entry.URL == "" || seen[entry.URL] {
+			continue
+		}
+		seen[entry.URL] = true
+		if entry.Province == "" {
+			entry.Province = pageProvince
+		}
+		entry.Sector = sectorOf(entry.Name, entry.URL)
+		entry.Source = source
+		result = append(result, entry)
+	}
+	return result
+}
+
+func (ce *ContentExtractor) templateDirectory(doc *goquery.Document, rule *DirectoryRule) []DirectoryEntry {
+	base := documentBaseURL(doc)
+	var entries []DirectoryEntry
+	selectAll(doc.Selection, rule.Item).Each(func(i int, item *goquery.Selection) {
+		link := item.Find("a[href]").First()
+		if rule.Link != "" {
+			link = selectAll(item, rule.Link).First()
+		}
+		name := ce.cleanText(link.Text())
+		if rule.Name != "" {
+			name = ce.cleanText(selectValue(item, rule.Name))
+		}
+
+		entry := DirectoryEntry{
+			Name:     name,
+			URL:      absoluteURL(base, link.AttrOr("href", "")),
+			Province: findProvince(selectValue(item, rule.Province)),
+			Category: ce.cleanText(selectValue(item, rule.Category)),
+		}
+		if entry.Province == "" {
+			entry.Province = findProvince(item.Text())
+		}
+		entries = append(entries, entry)
+	})
+	return entries
+}
+
+func (ce *ContentExtractor) heuristicDirectory(doc *goquery.Document) []DirectoryEntry {
+	base := documentBaseURL(doc)
+	var entries []DirectoryEntry
+
+	doc.Find("a[href]").Each(func(i int, a *goquery.Selection) {
+		if a.Closest(boilerplateSelector).Length() > 0 {
+			return
+		}
+		link := absoluteURL(base, a.AttrOr("href", ""))
+		name := ce.cleanText(firstNonEmpty(a.Text(), a.AttrOr("title", "")))
+		if !isMemberHomepage(base, link) || utf8.RuneCountInString(name) < 2 || utf8.RuneCountInString(name) > 40 {
+			return
+		}
+
+		entry := DirectoryEntry{Name: name, URL: link}
+		heading := ce.groupHeading(a)
+		row := a.Closest("tr, li, dd")
+		rowText := ""
+		if row.Length() > 0 {
+			rowText = strings.Replace(ce.cleanText(row.Text()), name, "", 1)
+		}
+
+		entry.Province = firstNonEmpty(findProvince(rowText), findProvince(heading))
+		if heading != "" && heading != entry.Province {
+			entry.Category = heading
+		}
+		entries = append(entries, entry)
+	})
+	return entries
+}
+
+// groupHeading 链接所在分组的标题：向上逐级查找前面的兄弟标题节点
+func (ce *ContentExtractor) groupHeading(s *goquery.Selection) string {
+	for node, level := s, 0; node.Length() > 0 && level < 6; node, level = node.Parent(), level+1 {
+		if heading := node.PrevAllFiltered(groupHeadingSelector).First(); heading.Length() > 0 {
+			if text := ce.cleanText(heading.Text()); text != "" && utf8.RuneCountInString(text) <= maxLabelRunes {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// isMemberHomepage 指向其他站点且路径不超过一级的 http 链接
+func isMemberHomepage(base *url.URL, link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	if base != nil && u.Hostname() == base.Hostname() {
+		return false
+	}
+	if PlatformOf(u.Hostname()) != "" || u.Hostname() == "beian.miit.gov.cn" {
+		return false
+	}
+	return strings.Count(strings.Trim(u.Path, "/"), "/") == 0
+}
+
+func findProvince(text string) string {
+	for _, province := range provinces {
+		if strings.Contains(text, province) {
+			return province
+		}
+	}
+	return ""
+}
+
+// sectorOf 按名称和域名判断行业
+func sectorOf(name string, link string) string {
+	host := ""
+	if u, err := url.Parse(link); err == nil {
+		host = u.Hostname()
+	}
+	switch {
+	case strings.Contains(name, "医院") || strings.Contains(name, "卫生院") || strings.Contains(name, "妇幼保健"):
+		return SectorHospital
+	case strings.Contains(name, "大学") || strings.Contains(name, "学院") || strings.HasSuffix(host, ".edu.cn"):
+		return SectorUniversity
+	case strings.HasSuffix(host, ".gov.cn") || strings.Contains(name, "政府") || strings.HasSuffix(name, "局") ||
+		strings.HasSuffix(name, "厅") || strings.HasSuffix(name, "委员会"):
+		return SectorGov
+	}
+	return ""
+}
+
+// HarvestDirectory 从目录页开始沿分页收集成员站点，按需校验首页可访问
+func (ce *ContentExtractor) HarvestDirectory(ctx context.Context, directoryURL string, opts HarvestOptions) ([]DirectoryEntry, error) {
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultHarvestPages
+	}
+
+	var entries []DirectoryEntry
+	seen := map[string]bool{}
+	visited := map[string]bool{}
+	for pageURL, page := directoryURL, 0; pageURL != "" && page < maxPages && !visited[pageURL]; page++ {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		visited[pageURL] = true
+
+		doc, err := FetchDocument(ctx, pageURL)
+		if err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			break
+		}
+		for _, entry := range ce.ExtractDirectory(doc) {
+			if !seen[entry.URL] {
+				seen[entry.URL] = true
+				entries = append(entries, entry)
+			}
+		}
+
+		pageURL = ""
+		if t := ce.Templates.Match(doc.Url); t != nil && t.NextPage != "" {
+			pageURL = absoluteURL(documentBaseURL(doc), firstAttr(selectAll(doc.Selection, t.NextPage).First(), "href", "data-href"))
+		}
+		if pagination := ce.DetectPagination(doc); pageURL == "" && pagination != nil {
+			pageURL = pagination.Next
+		}
+	}
+
+	if opts.Validate {
+		validateEntries(ctx, entries, opts.Concurrency)
+	}
+	return entries, nil
+}
+
+// validateEntries 并发请求首页，记录是否可访问及跳转后的地址
+func validateEntries(ctx context.Context, entries []DirectoryEntry, concurrency int) {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(entry *DirectoryEntry) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, entry.URL, nil)
+			if err != nil {
+				entry.Error = err.Error()
+				return
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				entry.Error = err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+			if resp.StatusCode >= http.StatusBadRequest {
+				entry.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+				return
+			}
+			entry.Resolved = true
+			entry.FinalURL = resp.Request.URL.String()
+		}(&entries[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,69 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHarvestDirectory(t *testing.T) {
+	var site string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dir/index.html", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><title>山东省政府网站导航</title></head><body>
+		<div class="group"><h3>省政府部门</h3><ul>
+			<li><a href="%s/">山东省教育厅</a></li>
+			<li><a href="%s/missing">山东省卫生健康委员会</a></li>
+		</ul></div>
+		<div class="group"><h3>济南市</h3><table><tr><td><a href="https://www.jinan.gov.cn/">济南市人民政府</a></td></tr></table></div>
+		<div class="page"><a href="index_1.html">下一页</a></div>
+		<div class="footer"><a href="https://beian.miit.gov.cn/">鲁ICP备00000000号</a></div>
+		</body></html>`, site, site)
+	})
+	mux.HandleFunc("/dir/index_1.html", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul>
+			<li><a href="https://www.sdu.edu.cn/">山东大学</a> 山东·济南</li>
+			<li><a href="https://www.qiluhospital.com/">山东大学齐鲁医院</a></li>
+			<li><a href="https://www.sdu.edu.cn/">山东大学</a></li>
+		</ul></body></html>`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	// 成员站点使用 localhost，与目录页的 127.0.0.1 视为不同站点
+	site = strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	entries, err := NewContentExtractor().HarvestDirectory(context.Background(), server.URL+"/dir/index.html", HarvestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	first := entries[0]
+	if first.Name != "山东省教育厅" || first.Province != "山东" || first.Category != "省政府部门" || first.Sector != SectorGov {
+		t.Errorf("first = %+v", first)
+	}
+	if entries[2].Category != "济南市" || entries[2].Sector != SectorGov {
+		t.Errorf("third = %+v", entries[2])
+	}
+	if entries[3].Sector != SectorUniversity || entries[3].Province != "山东" || entries[3].Source != server.URL+"/dir/index_1.html" {
+		t.Errorf("fourth = %+v", entries[3])
+	}
+	if entries[4].Sector != SectorHospital {
+		t.Errorf("fifth = %+v", entries[4])
+	}
+
+	validateEntries(context.Background(), entries[:2], 2)
+	if !entries[0].Resolved || entries[1].Resolved || entries[1].Error != "HTTP 404" {
+		t.Errorf("validated = %+v", entries[:2])
+	}
+}
@@ -0,0 +1,89 @@
+package crawl
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Document 采集得到的正文页
+type Document struct {
+	TaskID string `json:"task_id"`
+	URL    string `json:"url"`
+	// 与上次采集相比：new、changed，全量模式下还会有 unchanged
+	Change      string    `json:"change"`
+	ContentHash string    `json:"content_hash"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	// 正文的 SimHash 指纹，正文过短或未开启检测时为空
+	SimHash string `json:"simhash,omitempty"`
+	// 近似重复时为先采集到的规范地址及海明距离
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+	Distance    int    `json:"distance,omitempty"`
+	*ExtractedContent
+}
+
+// DocumentSink 正文存储
+type DocumentSink interface {
+	Save(doc *Document) error
+}
+
+// DocumentRemover 清理过期任务时删除其写入的正文，正文已被之后的任务覆盖时保留
+type DocumentRemover interface {
+	Remove(taskID string, pageURL string) error
+}
+
+// DirDocumentSink 将正文以 json 保存到本地目录：<dir>/<host>/<md5(url)>.json，页面变化时覆盖
+type DirDocumentSink struct {
+	dir string
+}
+
+func NewDirDocumentSink(dir string) *DirDocumentSink {
+	return &DirDocumentSink{dir: dir}
+}
+
+func (s *DirDocumentSink) Save(doc *Document) error {
+	path, err := s.path(doc.URL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (s *DirDocumentSink) Remove(taskID string, pageURL string) error {
+	path, err := s.path(pageURL)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var doc struct {
+		TaskID string `json:"task_id"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.TaskID != taskID {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+func (s *DirDocumentSink) path(pageURL string) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, u.Hostname(), genMD5(pageURL)+".json"), nil
+}
@@ -1 +1,191 @@
 package crawl
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// 近似重复检测
+// 政府网站常把同一篇通知挂在多个栏目、多个地址下。对正文计算 64 位 SimHash，
+// 海明距离不超过阈值的视为重复，以先采集到的地址为规范地址（canonical）。
+// 指纹按 阈值+1 段分桶（抽屉原理：距离不超过阈值的两个指纹至少有一段完全相同），只比较同桶的候选
+
+const (
+	DefaultDuplicateThreshold = 6
+	maxDuplicateThreshold     = 10
+)
+
+// DedupOptions 近似重复检测配置
+type DedupOptions struct {
+	Disabled bool `json:"disabled"`
+	// 海明距离阈值，为 0 时使用 DefaultDuplicateThreshold，最大 10
+	Threshold int `json:"threshold"`
+}
+
+// Validate 校验阈值范围
+func (o DedupOptions) Validate() error {
+	if o.Threshold < 0 || o.Threshold > maxDuplicateThreshold {
+		return fmt.Errorf("近似重复阈值应在 0-%d 之间: %d", maxDuplicateThreshold, o.Threshold)
+	}
+	return nil
+}
+
+// DuplicateMember 重复的页面
+type DuplicateMember struct {
+	URL      string `json:"url"`
+	Distance int    `json:"distance"`
+}
+
+// DuplicateCluster 规范地址及其重复页面
+type DuplicateCluster struct {
+	Canonical   string            `json:"canonical"`
+	Fingerprint string            `json:"fingerprint"`
+	Members     []DuplicateMember `json:"members"`
+}
+
+// DuplicateIndex 任务内的指纹索引，并发安全
+type DuplicateIndex struct {
+	threshold int
+
+	mu sync.Mutex
+	// 规范地址的指纹，按加入顺序
+	canonicals []string
+	prints     []uint64
+	// 每段的值 -> 规范地址下标
+	bands    []map[uint64][]int
+	clusters map[int][]DuplicateMember
+	dupes    int
+}
+
+// NewDuplicateIndex threshold 为 0 时使用默认阈值
+func NewDuplicateIndex(threshold int) *DuplicateIndex {
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+	if threshold > maxDuplicateThreshold {
+		threshold = maxDuplicateThreshold
+	}
+	d := &DuplicateIndex{threshold: threshold}
+	d.reset()
+	return d
+}
+
+func (d *DuplicateIndex) reset() {
+	d.canonicals, d.prints, d.dupes = nil, nil, 0
+	d.bands = make([]map[uint64][]int, d.threshold+1)
+	for i := range d.bands {
+		d.bands[i] = map[uint64][]int{}
+	}
+	d.clusters = map[int][]DuplicateMember{}
+}
+
+// add 加入新的规范地址（调用方持有锁）
+func (d *DuplicateIndex) add(pageURL string, fingerprint uint64) int {
+	idx := len(d.canonicals)
+	d.canonicals = append(d.canonicals, pageURL)
+	d.prints = append(d.prints, fingerprint)
+	for i := range d.bands {
+		key := d.band(fingerprint, i)
+		d.bands[i][key] = append(d.bands[i][key], idx)
+	}
+	return idx
+}
+
+// band 第 i 段的值
+func (d *DuplicateIndex) band(fingerprint uint64, i int) uint64 {
+	n := len(d.bands)
+	lo, hi := i*64/n, (i+1)*64/n
+	return (fingerprint >> uint(lo)) & (1<<uint(hi-lo) - 1)
+}
+
+// Check 查找与 fingerprint 近似重复的规范地址；没有时将 pageURL 作为新的规范地址加入索引。
+// 同一地址再次检查时返回自身，不计为重复
+func (d *DuplicateIndex) Check(pageURL string, fingerprint uint64) (canonical string, distance int, duplicate bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	best, bestDistance := -1, d.threshold+1
+	for i := range d.bands {
+		for _, idx := range d.bands[i][d.band(fingerprint, i)] {
+			if dist := HammingDistance(fingerprint, d.prints[idx]); dist < bestDistance || (dist == bestDistance && idx < best) {
+				best, bestDistance = idx, dist
+			}
+		}
+	}
+	if best >= 0 {
+		if d.canonicals[best] == pageURL {
+			return pageURL, 0, false
+		}
+		for _, member := range d.clusters[best] {
+			if member.URL == pageURL {
+				return d.canonicals[best], member.Distance, true
+			}
+		}
+		d.clusters[best] = append(d.clusters[best], DuplicateMember{URL: pageURL, Distance: bestDistance})
+		d.dupes++
+		return d.canonicals[best], bestDistance, true
+	}
+
+	d.add(pageURL, fingerprint)
+	return pageURL, 0, false
+}
+
+// Count 重复页面数
+func (d *DuplicateIndex) Count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dupes
+}
+
+// Clusters 有重复页面的规范地址，按发现顺序
+func (d *DuplicateIndex) Clusters() []DuplicateCluster {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	clusters := make([]DuplicateCluster, 0, len(d.clusters))
+	for idx, canonical := range d.canonicals {
+		members := d.clusters[idx]
+		if len(members) == 0 {
+			continue
+		}
+		clusters = append(clusters, DuplicateCluster{
+			Canonical:   canonical,
+			Fingerprint: fingerprintHex(d.prints[idx]),
+			Members:     append([]DuplicateMember(nil), members...),
+		})
+	}
+	return clusters
+}
+
+// State 全部规范地址（含没有重复页面的）及其重复页面，按加入顺序，用于写入检查点和任务记录
+func (d *DuplicateIndex) State() []DuplicateCluster {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	clusters := make([]DuplicateCluster, 0, len(d.canonicals))
+	for idx, canonical := range d.canonicals {
+		clusters = append(clusters, DuplicateCluster{
+			Canonical:   canonical,
+			Fingerprint: fingerprintHex(d.prints[idx]),
+			Members:     append([]DuplicateMember(nil), d.clusters[idx]...),
+		})
+	}
+	return clusters
+}
+
+// Restore 以 State 的结果替换索引内容，指纹无法解析的规范地址跳过
+func (d *DuplicateIndex) Restore(clusters []DuplicateCluster) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reset()
+	for _, cluster := range clusters {
+		fingerprint, err := strconv.ParseUint(cluster.Fingerprint, 16, 64)
+		if err != nil {
+			continue
+		}
+		idx := d.add(cluster.Canonical, fingerprint)
+		if len(cluster.Members) > 0 {
+			d.clusters[idx] = append([]DuplicateMember(nil), cluster.Members...)
+			d.dupes += len(cluster.Members)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package crawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// 抽取策略评测
+// 样本目录结构：每个 xxx.html 对应一个 xxx.json 标注文件，子目录名作为站点类别
+//
+//	fixtures/
+//	  gov/notice-1.html
+//	  gov/notice-1.json   {"title": "...", "author": "...", "pubtime": "2024-01-01", "content": "..."}
+//	  edu/news-1.html
+//	  edu/news-1.json
+
+// Golden 人工标注的抽取结果
+type Golden struct {
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	PubTime  string `json:"pubtime"`
+	Content  string `json:"content"`
+	Category string `json:"category"`
+	// 页面地址，可选，用于模板归纳时按 URL 分组
+	URL string `json:"url"`
+}
+
+// Fixture 评测样本
+type Fixture struct {
+	Name     string
+	Category string
+	HTML     string
+	Golden   Golden
+}
+
+// FieldScore 单字段命中统计
+type FieldScore struct {
+	Correct int `json:"correct"`
+	Total   int `json:"total"`
+}
+
+// Accuracy 准确率
+func (fs FieldScore) Accuracy() float64 {
+	if fs.Total == 0 {
+		return 0
+	}
+	return float64(fs.Correct) / float64(fs.Total)
+}
+
+func (fs *FieldScore) add(ok bool) {
+	fs.Total++
+	if ok {
+		fs.Correct++
+	}
+}
+
+// StrategyScore 某个策略在某个类别上的评测结果
+type StrategyScore struct {
+	Strategy string     `json:"strategy"`
+	Category string     `json:"category"`
+	Samples  int        `json:"samples"`
+	Failures int        `json:"failures"`
+	Title    FieldScore `json:"title"`
+	Author   FieldScore `json:"author"`
+	PubTime  FieldScore `json:"pubtime"`
+	// 正文 ROUGE-L F1 与字符级 F1 的平均值
+	ContentRouge  float64 `json:"content_rouge"`
+	ContentCharF1 float64 `json:"content_char_f1"`
+}
+
+// EvalReport 评测报告
+type EvalReport struct {
+	Scores []*StrategyScore `json:"scores"`
+}
+
+// LoadFixtures 加载评测样本目录
+func LoadFixtures(dir string) ([]Fixture, error) {
+	var fixtures []Fixture
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".html" && ext != ".htm") {
+			return nil
+		}
+
+		goldenPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+		raw, err := os.ReadFile(goldenPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// 没有标注的页面不参与评测
+				return nil
+			}
+			return err
+		}
+
+		var golden Golden
+		if err := json.Unmarshal(raw, &golden); err != nil {
+			return fmt.Errorf("解析标注文件失败 %s: %w", goldenPath, err)
+		}
+
+		html, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		category := golden.Category
+		if category == "" {
+			category = filepath.Dir(rel)
+			if category == "." {
+				category = "default"
+			}
+		}
+
+		fixtures = append(fixtures, Fixture{
+			Name:     rel,
+			Category: category,
+			HTML:     string(html),
+			Golden:   golden,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fixtures, nil
+}
+
+// Evaluate 使用指定策略（为空则使用全部已注册策略）对样本进行评测
+func Evaluate(fixtures []Fixture, strategies []string) (*EvalReport, error) {
+	if len(strategies) == 0 {
+		strategies = ExtractorNames()
+	}
+
+	report := &EvalReport{}
+	scores := map[string]*StrategyScore{}
+
+	for _, name := range strategies {
+		for _, fixture := range fixtures {
+			key := name + "\x00" + fixture.Category
+			score, ok := scores[key]
+			if !ok {
+				score = &StrategyScore{Strategy: name, Category: fixture.Category}
+				scores[key] = score
+				report.Scores = append(report.Scores, score)
+			}
+
+			extractor, err := NewExtractor(name)
+			if err != nil {
+				return nil, err
+			}
+
+			// 抽取器会修改文档，每个策略都重新解析
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(fixture.HTML))
+			if err != nil {
+				return nil, fmt.Errorf("解析样本失败 %s: %w", fixture.Name, err)
+			}
+
+			score.Samples++
+			content, err := extractor.Extract(doc)
+			if err != nil || content == nil {
+				score.Failures++
+				content = &ExtractedContent{}
+			}
+			score.score(content, fixture.Golden)
+		}
+	}
+
+	// 将累加值转换为平均值
+	for _, score := range report.Scores {
+		if score.Samples > 0 {
+			score.ContentRouge /= float64(score.Samples)
+			score.ContentCharF1 /= float64(score.Samples)
+		}
+	}
+
+	sort.SliceStable(report.Scores, func(i, j int) bool {
+		if report.Scores[i].Category != report.Scores[j].Category {
+			return report.Scores[i].Category < report.Scores[j].Category
+		}
+		return report.Scores[i].Strategy < report.Scores[j].Strategy
+	})
+
+	return report, nil
+}
+
+func (s *StrategyScore) score(content *ExtractedContent, golden Golden) {
+	s.Title.add(normalizeField(content.Title) == normalizeField(golden.Title))
+	s.Author.add(normalizeField(content.Author) == normalizeField(golden.Author))
+	s.PubTime.add(samePubDate(content, golden.PubTime))
+	s.ContentRouge += rougeL(content.Content, golden.Content)
+	s.ContentCharF1 += charF1(content.Content, golden.Content)
+}
+
+// Best 每个类别下正文得分最高的策略
+func (r *EvalReport) Best() map[string]string {
+	best := map[string]string{}
+	bestScore := map[string]float64{}
+	for _, score := range r.Scores {
+		value := score.ContentRouge + score.ContentCharF1
+		if current, ok := bestScore[score.Category]; !ok || value > current {
+			best[score.Category] = score.Strategy
+			bestScore[score.Category] = value
+		}
+	}
+	return best
+}
+
+// WriteTable 以表格形式输出报告
+func (r *EvalReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tSTRATEGY\tSAMPLES\tFAILURES\tTITLE\tAUTHOR\tPUBTIME\tROUGE-L\tCHAR-F1")
+	for _, s := range r.Scores {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.3f\t%.3f\n",
+			s.Category, s.Strategy, s.Samples, s.Failures,
+			s.Title.Accuracy(), s.Author.Accuracy(), s.PubTime.Accuracy(),
+			s.ContentRouge, s.ContentCharF1)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	best := r.Best()
+	categories := make([]string, 0, len(best))
+	for category := range best {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	fmt.Fprintln(w)
+	for _, category := range categories {
+		fmt.Fprintf(w, "best[%s] = %s\n", category, best[category])
+	}
+	return nil
+}
+
+func normalizeField(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// samePubDate 按天比较发布时间
+func samePubDate(content *ExtractedContent, golden string) bool {
+	golden = strings.TrimSpace(golden)
+	if golden == "" {
+		return content.PubTime.IsZero()
+	}
+	if content.PubTime.IsZero() {
+		return false
+	}
+	expected, err := NewContentExtractor().parseTime(golden)
+	if err != nil {
+		return false
+	}
+	return expected.Format("2006-01-02") == content.PubTime.Format("2006-01-02")
+}
+
+// rougeL 基于最长公共子序列的 F1
+func rougeL(candidate, reference string) float64 {
+	c := tokenize(candidate)
+	r := tokenize(reference)
+	if len(c) == 0 || len(r) == 0 {
+		if len(c) == len(r) {
+			return 1
+		}
+		return 0
+	}
+
+	prev := make([]int, len(r)+1)
+	curr := make([]int, len(r)+1)
+	for i := 1; i <= len(c); i++ {
+		for j := 1; j <= len(r); j++ {
+			if c[i-1] == r[j-1] {
+				curr[j] = prev[j-1] + 1
+			} else if prev[j] >= curr[j-1] {
+				curr[j] = prev[j]
+			} else {
+				curr[j] = curr[j-1]
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return f1(float64(prev[len(r)]), len(c), len(r))
+}
+
+// charF1 忽略空白后的字符多重集合 F1
+func charF1(candidate, reference string) float64 {
+	counts := map[rune]int{}
+	var candidateLen, referenceLen int
+	for _, r := range reference {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		counts[r]++
+		referenceLen++
+	}
+
+	var overlap int
+	for _, r := range candidate {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		candidateLen++
+		if counts[r] > 0 {
+			counts[r]--
+			overlap++
+		}
+	}
+
+	if candidateLen == 0 || referenceLen == 0 {
+		if candidateLen == referenceLen {
+			return 1
+		}
+		return 0
+	}
+	return f1(float64(overlap), candidateLen, referenceLen)
+}
+
+func f1(overlap float64, candidateLen, referenceLen int) float64 {
+	if overlap == 0 {
+		return 0
+	}
+	precision := overlap / float64(candidateLen)
+	recall := overlap / float64(referenceLen)
+	return 2 * precision * recall / (precision + recall)
+}
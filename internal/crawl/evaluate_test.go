@@ -0,0 +1,64 @@
+package crawl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "gov"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	html := `<html><head><title>关于开展安全检查的通知</title></head><body>
+	<div class="content">
+		<p>各单位：为进一步做好安全生产工作，切实保障人民群众生命财产安全，现就开展安全检查有关事项通知如下。</p>
+		<p>一、检查时间为二〇二四年一月，各单位要高度重视，认真组织，确保检查工作取得实效，不留死角。</p>
+	</div></body></html>`
+	golden := `{"title": "关于开展安全检查的通知", "content": "各单位：为进一步做好安全生产工作，切实保障人民群众生命财产安全，现就开展安全检查有关事项通知如下。"}`
+
+	if err := os.WriteFile(filepath.Join(dir, "gov", "notice.html"), []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gov", "notice.json"), []byte(golden), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 1 || fixtures[0].Category != "gov" {
+		t.Fatalf("unexpected fixtures: %+v", fixtures)
+	}
+
+	report, err := Evaluate(fixtures, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Scores) != len(ExtractorNames()) {
+		t.Fatalf("expected one score per strategy, got %d", len(report.Scores))
+	}
+	for _, score := range report.Scores {
+		if score.Title.Accuracy() != 1 {
+			t.Errorf("%s: title accuracy = %.2f", score.Strategy, score.Title.Accuracy())
+		}
+		if score.Strategy == "density" && score.ContentCharF1 == 0 {
+			t.Errorf("%s: content char f1 is zero", score.Strategy)
+		}
+	}
+}
+
+func TestRougeL(t *testing.T) {
+	if got := rougeL("今天天气很好", "今天天气很好"); got != 1 {
+		t.Fatalf("identical text rouge = %.2f", got)
+	}
+	if got := rougeL("hello world", "goodbye"); got != 0 {
+		t.Fatalf("disjoint text rouge = %.2f", got)
+	}
+	if got := charF1("abcd", "ab"); got < 0.66 || got > 0.67 {
+		t.Fatalf("char f1 = %.3f", got)
+	}
+}
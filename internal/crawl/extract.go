@@ -1,11 +1,14 @@
 package crawl
 
 import (
+	"context"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"net/http"
+	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -21,21 +24,50 @@ type ContentExtractor struct {
 	TextDensityThreshold float64
 	// 最小文本长度
 	MinTextLength int
+	// 正文拼装方式，默认选取单个最佳容器
+	Mode ContentMode
+	// 额外输出格式（Markdown 或结构化正文块）
+	Format OutputFormat
 	// 标题相关选择器
 	TitleSelectors []string
 	// 时间相关选择器
 	TimeSelectors []string
 	// 作者相关选择器
 	AuthorSelectors []string
+	// 站点模板，命中时优先于启发式规则
+	Templates *TemplateStore
+	// 列表页识别，识别为列表页时抽取列表条目；为空时不识别
+	ListAnalyzer *PageAnalyzer
 }
 
 // ExtractedContent 提取的内容结构
 type ExtractedContent struct {
-	Title     string     `json:"title"`
-	Author    string     `json:"author"`
-	PubTime   time.Time  `json:"pub_time"`
-	Content   string     `json:"content"`
-	TextNodes []TextNode `json:"text_nodes"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"`
+	PubTime     time.Time  `json:"pub_time"`
+	Description string     `json:"description,omitempty"`
+	Canonical   string     `json:"canonical,omitempty"`
+	Content     string     `json:"content"`
+	TextNodes   []TextNode `json:"text_nodes"`
+	// 正文容器节点的 CSS 选择器（仅 container 模式）
+	NodePath string `json:"node_path,omitempty"`
+	// Format 为 markdown 时输出
+	Markdown string `json:"markdown,omitempty"`
+	// Format 为 blocks 时输出
+	Blocks []Block `json:"blocks,omitempty"`
+	// 正文中的图片、附件和视频
+	Images      []Image      `json:"images,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Videos      []Video      `json:"videos,omitempty"`
+	// 结构化元数据
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// 命中的站点模板名称
+	Template string `json:"template,omitempty"`
+	// 列表页条目（模板定义或启发式识别）与下一页
+	ListItems []ListItem `json:"list_items,omitempty"`
+	NextPage  string     `json:"next_page,omitempty"`
+	// 识别到的分页链接
+	Pagination *Pagination `json:"pagination,omitempty"`
 }
 
 // TextNode 文本节点信息
@@ -53,6 +85,9 @@ func NewContentExtractor() *ContentExtractor {
 	return &ContentExtractor{
 		TextDensityThreshold: 1.0,
 		MinTextLength:        50,
+		Mode:                 ContentModeContainer,
+		Format:               OutputText,
+		ListAnalyzer:         NewPageAnalyzer(),
 		TitleSelectors: []string{
 			"title",
 			"h1",
@@ -90,14 +125,23 @@ func NewContentExtractor() *ContentExtractor {
 }
 
 // ExtractFromURL 从URL提取内容
-func (ce *ContentExtractor) ExtractFromURL(url string) (*ExtractedContent, error) {
-	resp, err := http.Get(url)
+func (ce *ContentExtractor) ExtractFromURL(ctx context.Context, url string) (*ExtractedContent, error) {
+	doc, err := FetchDocument(ctx, url)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	return ce.ExtractFromDocument(doc)
+}
+
+// ExtractFromHTML 从HTML字符串提取内容
+func (ce *ContentExtractor) ExtractFromHTML(html string) (*ExtractedContent, error) {
+	return ce.ExtractFromHTMLWithURL(html, "")
+}
+
+// ExtractFromHTMLWithURL 从HTML字符串提取内容，pageURL 为页面地址（可为空）
+func (ce *ContentExtractor) ExtractFromHTMLWithURL(html string, pageURL string) (*ExtractedContent, error) {
+	doc, err := ParseDocument(html, pageURL)
 	if err != nil {
 		return nil, err
 	}
@@ -105,47 +149,139 @@ func (ce *ContentExtractor) ExtractFromURL(url string) (*ExtractedContent, error
 	return ce.ExtractFromDocument(doc)
 }
 
-// ExtractFromHTML 从HTML字符串提取内容
-func (ce *ContentExtractor) ExtractFromHTML(html string) (*ExtractedContent, error) {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+// DefaultFetchTimeout 单个页面的下载超时
+const DefaultFetchTimeout = 30 * time.Second
+
+// fetchClient 接口中抽取、模板测试、目录收集共用的客户端
+var fetchClient = &http.Client{Timeout: DefaultFetchTimeout}
+
+// FetchDocument 下载并解析页面，记录最终地址用于解析相对链接；ctx 取消（如接口请求断开）时中止下载
+func FetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	return ce.ExtractFromDocument(doc)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc.Url = resp.Request.URL
+	return doc, nil
 }
 
-// ExtractFromDocument 从goquery文档提取内容
+// ParseDocument 解析HTML字符串，pageURL 为页面地址（可为空）
+func ParseDocument(html string, pageURL string) (*goquery.Document, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+	if pageURL != "" {
+		if doc.Url, err = url.Parse(pageURL); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// ExtractFromDocument 从goquery文档提取内容，页面命中站点模板时优先使用模板
 func (ce *ContentExtractor) ExtractFromDocument(doc *goquery.Document) (*ExtractedContent, error) {
-	content := &ExtractedContent{}
+	return ce.ExtractWithTemplate(doc, ce.Templates.Match(doc.Url))
+}
 
-	// 提取标题
-	content.Title = ce.extractTitle(doc)
+// ExtractWithTemplate 使用指定模板提取内容，模板未覆盖的字段回退到启发式规则；t 为空时等同于纯启发式
+func (ce *ContentExtractor) ExtractWithTemplate(doc *goquery.Document, t *Template) (*ExtractedContent, error) {
+	content := &ExtractedContent{}
 
-	// 提取作者
-	content.Author = ce.extractAuthor(doc)
+	// 提取标题、作者、发布时间等
+	ce.extractFields(doc, content)
 
-	// 提取发布时间
-	content.PubTime = ce.extractPubTime(doc)
+	// 在移除无关元素之前定位容器，保证节点路径对原始页面有效
+	var container *goquery.Selection
+	if t != nil {
+		container = ce.applyTemplate(doc, t, content)
+	}
+	if container == nil && ce.Mode != ContentModeNodes {
+		container = ce.selectContainer(doc)
+	}
+	if container != nil {
+		content.NodePath = nodePath(container)
+	}
+	// 模板未定义列表规则时，列表页使用启发式识别条目
+	if (t == nil || t.List == nil) && ce.ListAnalyzer != nil {
+		if isList, _ := ce.ListAnalyzer.AnalyzeDocument(doc); isList {
+			content.ListItems = ce.ExtractListItems(doc)
+		}
+	}
+	if pagination := ce.DetectPagination(doc); pagination != nil {
+		content.Pagination = pagination
+		if content.NextPage == "" {
+			content.NextPage = pagination.Next
+		}
+	}
+	if t != nil {
+		t.removeBoilerplate(doc)
+	}
 
 	// 提取正文内容
 	textNodes := ce.extractTextNodes(doc)
 	content.TextNodes = textNodes
-	content.Content = ce.extractMainContent(textNodes)
+	base := documentBaseURL(doc)
+	var blocks []Block
+	if container != nil {
+		blocks = ce.assembleBlocks(container, base)
+		content.Content = blocksText(blocks)
+		ce.extractMedia(container, base, content)
+	} else {
+		content.Content = ce.extractMainContent(textNodes)
+		for _, part := range strings.Split(content.Content, "\n\n") {
+			if part != "" {
+				blocks = append(blocks, Block{Type: BlockParagraph, Text: part, markdown: part})
+			}
+		}
+		ce.extractMedia(doc.Find("body"), base, content)
+	}
+
+	switch ce.Format {
+	case OutputMarkdown:
+		content.Markdown = blocksMarkdown(blocks)
+	case OutputBlocks:
+		content.Blocks = blocks
+	}
 
 	return content, nil
 }
 
+// extractFields 提取标题、作者、发布时间、摘要和规范地址
+// 结构化元数据优先，缺失时再使用选择器启发式（必须在移除 script 等元素之前调用）
+func (ce *ContentExtractor) extractFields(doc *goquery.Document, content *ExtractedContent) {
+	meta := ce.extractMetadata(doc)
+	content.Metadata = meta
+
+	content.Title = firstNonEmpty(meta.Title, ce.extractTitle(doc))
+	content.Author = firstNonEmpty(meta.Author, ce.extractAuthor(doc))
+	content.Description = meta.Description
+	content.Canonical = meta.Canonical
+
+	if meta.PublishedTime != "" {
+		if t, err := ce.parseTime(meta.PublishedTime); err == nil {
+			content.PubTime = t
+		}
+	}
+	if content.PubTime.IsZero() {
+		content.PubTime = ce.extractPubTime(doc)
+	}
+}
+
 // extractTitle 提取标题
 func (ce *ContentExtractor) extractTitle(doc *goquery.Document) string {
 	for _, selector := range ce.TitleSelectors {
-		if title := strings.TrimSpace(doc.Find(selector).First().Text()); title != "" {
-			// 对于meta标签，获取content属性
-			if strings.Contains(selector, "[") {
-				if content, exists := doc.Find(selector).First().Attr("content"); exists && content != "" {
-					return content
-				}
-			}
+		if title := selectorValue(doc, selector); title != "" {
 			return title
 		}
 	}
@@ -155,19 +291,22 @@ func (ce *ContentExtractor) extractTitle(doc *goquery.Document) string {
 // extractAuthor 提取作者
 func (ce *ContentExtractor) extractAuthor(doc *goquery.Document) string {
 	for _, selector := range ce.AuthorSelectors {
-		if author := strings.TrimSpace(doc.Find(selector).First().Text()); author != "" {
-			// 对于meta标签，获取content属性
-			if strings.Contains(selector, "[") {
-				if content, exists := doc.Find(selector).First().Attr("content"); exists && content != "" {
-					return content
-				}
-			}
+		if author := selectorValue(doc, selector); author != "" {
 			return author
 		}
 	}
 	return ""
 }
 
+// selectorValue 选择器命中元素的值，meta 等无文本的标签读取 content 属性
+func selectorValue(doc *goquery.Document, selector string) string {
+	element := doc.Find(selector).First()
+	if content, exists := element.Attr("content"); exists && strings.TrimSpace(content) != "" {
+		return strings.TrimSpace(content)
+	}
+	return strings.TrimSpace(element.Text())
+}
+
 // extractPubTime 提取发布时间
 func (ce *ContentExtractor) extractPubTime(doc *goquery.Document) time.Time {
 	for _, selector := range ce.TimeSelectors {
@@ -204,6 +343,10 @@ func (ce *ContentExtractor) parseTime(timeStr string) (time.Time, error) {
 	formats := []string{
 		time.RFC3339,
 		"2006-01-02T15:04:05Z07:00",
+		"2006-01-02T15:04:05.000Z07:00",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02 15:04",
 		"2006-01-02 15:04:05",
 		"2006-01-02",
 		"2006/01/02 15:04:05",
@@ -216,21 +359,49 @@ func (ce *ContentExtractor) parseTime(timeStr string) (time.Time, error) {
 		"January 2, 2006",
 	}
 
+	timeStr = strings.TrimSpace(timeStr)
 	for _, format := range formats {
 		if t, err := time.Parse(format, timeStr); err == nil {
 			return t, nil
 		}
 	}
 
+	// 文本中夹带的日期，如 "发布时间：2024年1月1日 10:00"
+	if t, ok := findDate(timeStr); ok {
+		return t, nil
+	}
+
 	return time.Time{}, fmt.Errorf("无法解析时间: %s", timeStr)
 }
 
+// dateRe 匹配 2024-01-01、2024/1/1、2024.01.01、2024年1月1日，可带时分秒
+var dateRe = regexp.MustCompile(`(\d{4})\s*[-/.年]\s*(\d{1,2})\s*[-/.月]\s*(\d{1,2})\s*日?(?:\s*(\d{1,2})[:：时](\d{1,2})(?:[:：分](\d{1,2}))?)?`)
+
+// findDate 从任意文本中找出第一个日期
+func findDate(text string) (time.Time, bool) {
+	m := dateRe.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	var parts [6]int
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(m[i+1])
+	}
+	year, month, day, hour, minute, second := parts[0], parts[1], parts[2], parts[3], parts[4], parts[5]
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, false
+	}
+
+	return time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC), true
+}
+
 // extractTextNodes 提取所有文本节点并计算密度
 func (ce *ContentExtractor) extractTextNodes(doc *goquery.Document) []TextNode {
 	var textNodes []TextNode
 
 	// 移除不需要的元素
-	doc.Find("script, style, nav, header, footer, aside, .sidebar, .ad, .advertisement").Remove()
+	doc.Find(boilerplateSelector).Remove()
 
 	// 遍历所有可能包含正文的元素
 	contentSelectors := []string{"p", "div", "article", "section", "main", "content"}
@@ -381,9 +552,7 @@ func (ae *AdvancedExtractor) ExtractWithBoilerpipe(doc *goquery.Document) (*Extr
 	content := &ExtractedContent{}
 
 	// 提取标题、作者、时间
-	content.Title = ae.extractTitle(doc)
-	content.Author = ae.extractAuthor(doc)
-	content.PubTime = ae.extractPubTime(doc)
+	ae.extractFields(doc, content)
 
 	// 使用Boilerpipe类似算法
 	blocks := ae.extractTextBlocks(doc)
@@ -0,0 +1,88 @@
+package crawl
+
+import (
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"sort"
+	"sync"
+)
+
+// Extractor 正文抽取策略
+type Extractor interface {
+	// Name 策略名称，用于注册和评测报告
+	Name() string
+	// Extract 从文档中抽取内容（实现可能会修改 doc）
+	Extract(doc *goquery.Document) (*ExtractedContent, error)
+}
+
+// ExtractorFactory 创建抽取策略实例
+type ExtractorFactory func() Extractor
+
+var (
+	extractorMu sync.RWMutex
+	extractors  = map[string]ExtractorFactory{}
+)
+
+func init() {
+	RegisterExtractor("density", func() Extractor { return NewContentExtractor() })
+	RegisterExtractor("density-nodes", func() Extractor {
+		ce := NewContentExtractor()
+		ce.Mode = ContentModeNodes
+		return ce
+	})
+	RegisterExtractor("boilerpipe", func() Extractor { return NewAdvancedExtractor() })
+}
+
+// RegisterExtractor 注册抽取策略，同名策略会被覆盖
+func RegisterExtractor(name string, factory ExtractorFactory) {
+	extractorMu.Lock()
+	defer extractorMu.Unlock()
+	extractors[name] = factory
+}
+
+// NewExtractor 按名称创建抽取策略
+func NewExtractor(name string) (Extractor, error) {
+	extractorMu.RLock()
+	factory, ok := extractors[name]
+	extractorMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的抽取策略: %s", name)
+	}
+	return factory(), nil
+}
+
+// ExtractorNames 已注册的策略名称（有序）
+func ExtractorNames() []string {
+	extractorMu.RLock()
+	defer extractorMu.RUnlock()
+
+	names := make([]string, 0, len(extractors))
+	for name := range extractors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name 实现 Extractor
+func (ce *ContentExtractor) Name() string {
+	if ce.Mode == ContentModeNodes {
+		return "density-nodes"
+	}
+	return "density"
+}
+
+// Extract 实现 Extractor
+func (ce *ContentExtractor) Extract(doc *goquery.Document) (*ExtractedContent, error) {
+	return ce.ExtractFromDocument(doc)
+}
+
+// Name 实现 Extractor
+func (ae *AdvancedExtractor) Name() string {
+	return "boilerpipe"
+}
+
+// Extract 实现 Extractor
+func (ae *AdvancedExtractor) Extract(doc *goquery.Document) (*ExtractedContent, error) {
+	return ae.ExtractWithBoilerpipe(doc)
+}
@@ -0,0 +1,345 @@
+package crawl
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 采集队列（Frontier）
+// 待采集地址统一进入 Frontier，由固定数量的 worker 按策略取出后交给 colly 同步请求，
+// 采集顺序不再取决于 colly 异步调度：
+// bfs 按层推进，dfs 先沿一条路径深入，best 按优先级（栏目/列表页、新 URL 模式优先，越深越靠后）取出，
+// 预算有限时尽早覆盖最有价值的栏目页
+
+// Strategy 采集顺序
+type Strategy string
+
+const (
+	StrategyBFS  Strategy = "bfs"
+	StrategyDFS  Strategy = "dfs"
+	StrategyBest Strategy = "best"
+)
+
+// DefaultWorkers 同时进行的请求数，单站点并发另由 Politeness 限制
+const DefaultWorkers = 32
+
+// ParseStrategy 空字符串视为 bfs
+func ParseStrategy(s string) (Strategy, error) {
+	switch strategy := Strategy(strings.ToLower(strings.TrimSpace(s))); strategy {
+	case "":
+		return StrategyBFS, nil
+	case StrategyBFS, StrategyDFS, StrategyBest:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("不支持的采集策略: %s", s)
+	}
+}
+
+// FrontierItem 待采集地址
+type FrontierItem struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+	// 发现该地址的页面
+	Parent string `json:"parent,omitempty"`
+	// 锚文本，用于计算优先级
+	Anchor   string  `json:"anchor,omitempty"`
+	Priority float64 `json:"priority"`
+	// 已重试的次数
+	Retries int `json:"retries,omitempty"`
+}
+
+// Frontier 待采集队列，按地址去重，并发安全
+type Frontier interface {
+	// Push 加入队列，已加入过的地址返回 false
+	Push(item FrontierItem) bool
+	// Pop 取出下一个地址，队列为空时返回 false
+	Pop() (FrontierItem, bool)
+	Len() int
+	// Snapshot 待采集地址（按入队顺序）、已发现的全部地址及各 URL 模式已入队的次数
+	Snapshot() (pending []FrontierItem, seen []string, patterns map[string]int)
+	// Restore 标记 seen 为已发现，并将 pending 按顺序重新加入队列（保留原优先级），
+	// patterns 不为空时恢复 URL 模式计数，之后新地址的优先级与中断前一致
+	Restore(pending []FrontierItem, seen []string, patterns map[string]int)
+}
+
+// NewFrontier 按策略创建队列
+func NewFrontier(strategy Strategy) Frontier {
+	return &frontier{strategy: strategy, seen: map[string]bool{}, patterns: map[string]int{}}
+}
+
+type frontier struct {
+	strategy Strategy
+
+	mu    sync.Mutex
+	items frontierHeap
+	seen  map[string]bool
+	// 入队序号
+	seq int
+	// 各 URL 模式已入队的次数
+	patterns map[string]int
+}
+
+func (f *frontier) Push(item FrontierItem) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.seen[item.URL] {
+		return false
+	}
+	f.seen[item.URL] = true
+
+	if f.strategy == StrategyBest {
+		item.Priority = f.score(item)
+	}
+	f.push(item)
+	return true
+}
+
+func (f *frontier) push(item FrontierItem) {
+	f.seq++
+	seq := f.seq
+	if f.strategy == StrategyDFS {
+		seq = -seq
+	}
+	heap.Push(&f.items, frontierEntry{item: item, key: f.key(item), seq: seq})
+}
+
+func (f *frontier) Pop() (FrontierItem, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.items.Len() == 0 {
+		return FrontierItem{}, false
+	}
+	return heap.Pop(&f.items).(frontierEntry).item, true
+}
+
+func (f *frontier) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.items.Len()
+}
+
+func (f *frontier) Snapshot() ([]FrontierItem, []string, map[string]int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// 按入队顺序排列，Restore 依次重新入队后出队顺序不变（dfs 的 seq 为负，不能直接按 seq 排）
+	entries := append(frontierHeap(nil), f.items...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].order() < entries[j].order() })
+	pending := make([]FrontierItem, 0, len(entries))
+	for _, entry := range entries {
+		pending = append(pending, entry.item)
+	}
+	seen := make([]string, 0, len(f.seen))
+	for u := range f.seen {
+		seen = append(seen, u)
+	}
+	sort.Strings(seen)
+	patterns := make(map[string]int, len(f.patterns))
+	for pattern, n := range f.patterns {
+		patterns[pattern] = n
+	}
+	return pending, seen, patterns
+}
+
+func (f *frontier) Restore(pending []FrontierItem, seen []string, patterns map[string]int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range seen {
+		f.seen[u] = true
+	}
+	for pattern, n := range patterns {
+		f.patterns[pattern] = n
+	}
+	for _, item := range pending {
+		f.seen[item.URL] = true
+		f.push(item)
+	}
+}
+
+// key 出队排序键，越大越先出队：bfs 浅层优先，dfs 深层优先，best 按优先级
+func (f *frontier) key(item FrontierItem) float64 {
+	switch f.strategy {
+	case StrategyDFS:
+		return float64(item.Depth)
+	case StrategyBest:
+		return item.Priority
+	default:
+		return -float64(item.Depth)
+	}
+}
+
+// score 优先级：疑似栏目/列表页加分，详情页减分，首次出现的 URL 模式加分，每深一层减分
+func (f *frontier) score(item FrontierItem) float64 {
+	score := 0.0
+	switch {
+	case isListPageByURL(item.URL):
+		score += 2
+	case isDetailURL(item.URL):
+		score--
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(item.Anchor)); n >= 2 && n <= maxLabelRunes && !isDetailURL(item.URL) {
+		score++
+	}
+
+	pattern := URLPatternOf(item.URL)
+	if u, err := url.Parse(item.URL); err == nil {
+		pattern = u.Hostname() + pattern
+	}
+	score += 2 / float64(1+f.patterns[pattern])
+	f.patterns[pattern]++
+
+	return score - 0.5*float64(item.Depth)
+}
+
+type frontierEntry struct {
+	item FrontierItem
+	key  float64
+	// dfs 取负值，使后入队者先出
+	seq int
+}
+
+// order 入队顺序
+func (e frontierEntry) order() int {
+	if e.seq < 0 {
+		return -e.seq
+	}
+	return e.seq
+}
+
+// frontierHeap key 大者优先，相同时 seq 小者优先
+type frontierHeap []frontierEntry
+
+func (h frontierHeap) Len() int { return len(h) }
+func (h frontierHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key > h[j].key
+	}
+	return h[i].seq < h[j].seq
+}
+func (h frontierHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *frontierHeap) Push(x interface{}) { *h = append(*h, x.(frontierEntry)) }
+func (h *frontierHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// dispatcher 由多个 worker 从 Frontier 取出地址并采集，队列为空且没有进行中的采集时结束
+type dispatcher struct {
+	frontier Frontier
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+	// 进行中的地址，写检查点时视为待采集
+	visiting map[*FrontierItem]bool
+	// 站点暂停期间移出队列、到时放回的地址，同样视为待采集
+	deferred map[*FrontierItem]bool
+}
+
+func newDispatcher(frontier Frontier) *dispatcher {
+	d := &dispatcher{frontier: frontier, visiting: map[*FrontierItem]bool{}, deferred: map[*FrontierItem]bool{}}
+	d.cond = sync.NewCond(&d.mu)
+	return d
+}
+
+// push 加入队列并唤醒空闲的 worker
+func (d *dispatcher) push(item FrontierItem) bool {
+	if !d.frontier.Push(item) {
+		return false
+	}
+	d.mu.Lock()
+	d.cond.Broadcast()
+	d.mu.Unlock()
+	return true
+}
+
+// requeue 将未能采集的地址放回队列
+func (d *dispatcher) requeue(item FrontierItem) {
+	d.frontier.Restore([]FrontierItem{item}, nil, nil)
+}
+
+// requeueAt 站点暂停时将地址移出队列，到 at 时再放回，期间不占用 worker，也不会让其他 worker 反复取到它
+func (d *dispatcher) requeueAt(item FrontierItem, at time.Time) {
+	d.mu.Lock()
+	d.deferred[&item] = true
+	d.mu.Unlock()
+	time.AfterFunc(time.Until(at), func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.deferred, &item)
+		d.frontier.Restore([]FrontierItem{item}, nil, nil)
+		d.cond.Broadcast()
+	})
+}
+
+// snapshot 待采集地址（含进行中的及暂缓的）、已发现的全部地址及 URL 模式计数
+func (d *dispatcher) snapshot() ([]FrontierItem, []string, map[string]int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pending, seen, patterns := d.frontier.Snapshot()
+	// 刚暂缓的地址在 visit 返回前同时处于 visiting 和 deferred 中，只保留一份
+	deferred := map[string]bool{}
+	for item := range d.deferred {
+		pending = append(pending, *item)
+		deferred[item.URL] = true
+	}
+	for item := range d.visiting {
+		if !deferred[item.URL] {
+			pending = append(pending, *item)
+		}
+	}
+	return pending, seen, patterns
+}
+
+// run 启动 workers 个 worker，阻塞至采集完成；ctx 取消后不再取出新地址，等待进行中的采集结束后返回
+func (d *dispatcher) run(ctx context.Context, workers int, visit func(FrontierItem)) {
+	stop := context.AfterFunc(ctx, func() {
+		d.mu.Lock()
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx, visit)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *dispatcher) work(ctx context.Context, visit func(FrontierItem)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if item, ok := d.frontier.Pop(); ok {
+			d.active++
+			d.visiting[&item] = true
+			d.mu.Unlock()
+			visit(item)
+			d.mu.Lock()
+			delete(d.visiting, &item)
+			d.active--
+			d.cond.Broadcast()
+			continue
+		}
+		if d.active == 0 && len(d.deferred) == 0 {
+			return
+		}
+		d.cond.Wait()
+	}
+}
@@ -0,0 +1,100 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFrontier(t *testing.T) {
+	items := []FrontierItem{
+		{URL: "https://www.example.gov.cn/", Depth: 1},
+		{URL: "https://www.example.gov.cn/art/2024/6/1/art_1_101.html", Depth: 2, Anchor: "关于开展2024年度安全生产检查的通知"},
+		{URL: "https://www.example.gov.cn/col/col12/index.html", Depth: 2, Anchor: "通知公告"},
+		{URL: "https://www.example.gov.cn/art/2024/6/2/art_1_102.html", Depth: 3},
+		{URL: "https://www.example.gov.cn/col/col13/index.html", Depth: 3, Anchor: "政策解读"},
+	}
+	order := func(strategy Strategy) []string {
+		f := NewFrontier(strategy)
+		for _, item := range items {
+			f.Push(item)
+		}
+		if f.Push(items[0]) {
+			t.Errorf("%s: duplicate push accepted", strategy)
+		}
+		var urls []string
+		for item, ok := f.Pop(); ok; item, ok = f.Pop() {
+			urls = append(urls, item.URL)
+		}
+		return urls
+	}
+
+	if got := order(StrategyBFS); got[0] != items[0].URL || got[1] != items[1].URL || got[4] != items[4].URL {
+		t.Errorf("bfs = %v", got)
+	}
+	if got := order(StrategyDFS); got[0] != items[4].URL || got[1] != items[3].URL || got[4] != items[0].URL {
+		t.Errorf("dfs = %v", got)
+	}
+	// 栏目页先于更浅的首页和详情页
+	if got := order(StrategyBest); got[0] != items[2].URL || got[1] != items[4].URL || got[2] != items[0].URL {
+		t.Errorf("best = %v", got)
+	}
+
+	// 快照恢复后出队顺序不变
+	for _, strategy := range []Strategy{StrategyBFS, StrategyDFS, StrategyBest} {
+		f, g := NewFrontier(strategy), NewFrontier(strategy)
+		for _, item := range items {
+			f.Push(item)
+		}
+		g.Restore(f.Snapshot())
+		for f.Len() > 0 {
+			want, _ := f.Pop()
+			if got, _ := g.Pop(); got != want {
+				t.Errorf("%s: restored pop = %s, want %s", strategy, got.URL, want.URL)
+			}
+		}
+	}
+
+	// dispatcher 处理完 visit 中新加入的地址后结束
+	d := newDispatcher(NewFrontier(StrategyBFS))
+	d.push(FrontierItem{URL: "/", Depth: 1})
+	var mu sync.Mutex
+	var visited []string
+	d.run(context.Background(), 4, func(item FrontierItem) {
+		mu.Lock()
+		visited = append(visited, item.URL)
+		mu.Unlock()
+		if item.Depth < 3 {
+			d.push(FrontierItem{URL: item.URL + "a/", Depth: item.Depth + 1})
+			d.push(FrontierItem{URL: item.URL + "b/", Depth: item.Depth + 1})
+		}
+	})
+	sort.Strings(visited)
+	if len(visited) != 7 || visited[0] != "/" || visited[6] != "/b/b/" {
+		t.Errorf("visited = %v", visited)
+	}
+
+	// 暂缓的地址不占用 worker，其他地址先采集，到时放回后采集完才结束
+	d = newDispatcher(NewFrontier(StrategyBFS))
+	d.push(FrontierItem{URL: "/paused", Depth: 1})
+	d.push(FrontierItem{URL: "/other", Depth: 2})
+	visited = nil
+	deferred := false
+	d.run(context.Background(), 1, func(item FrontierItem) {
+		if item.URL == "/paused" && !deferred {
+			deferred = true
+			d.requeueAt(item, time.Now().Add(50*time.Millisecond))
+			if pending, _, _ := d.snapshot(); len(pending) != 2 {
+				t.Errorf("snapshot while deferred = %v", pending)
+			}
+			return
+		}
+		visited = append(visited, item.URL)
+	})
+	if fmt.Sprint(visited) != "[/other /paused]" {
+		t.Errorf("visited with deferred = %v", visited)
+	}
+}
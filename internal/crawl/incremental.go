@@ -0,0 +1,166 @@
+package crawl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 增量采集
+// 每次采集都按地址记录 ETag、Last-Modified 和正文哈希（跨任务保存在本地目录，每个站点一个文件）。
+// 增量模式下再次采集正文页时带上 If-None-Match/If-Modified-Since，304 直接跳过；
+// 服务端不支持条件请求时比较正文哈希，只把新增或变化的正文写入 DocumentSink。
+// 首页和列表页（hub）始终完整请求，以便发现新的链接
+
+// CrawlMode 采集模式
+type CrawlMode string
+
+const (
+	CrawlFull        CrawlMode = "full"
+	CrawlIncremental CrawlMode = "incremental"
+)
+
+// 与上次采集相比的页面变化
+const (
+	ChangeNew         = "new"
+	ChangeChanged     = "changed"
+	ChangeUnchanged   = "unchanged"
+	ChangeNotModified = "not_modified"
+)
+
+// ParseCrawlMode 空字符串视为 full
+func ParseCrawlMode(s string) (CrawlMode, error) {
+	switch mode := CrawlMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return CrawlFull, nil
+	case CrawlFull, CrawlIncremental:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("不支持的采集模式: %s", s)
+	}
+}
+
+// ChangeStats 任务内各类变化的页面数
+type ChangeStats struct {
+	New         int `json:"new"`
+	Changed     int `json:"changed"`
+	Unchanged   int `json:"unchanged"`
+	NotModified int `json:"not_modified"`
+}
+
+// PageState 页面上次采集的状态
+type PageState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	ContentHash  string `json:"content_hash"`
+	// 首页或列表页，始终完整请求
+	Hub       bool      `json:"hub,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+	// 最近一次确认（含 304）的时间
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// PageStateStore 页面状态，按站点分文件保存，并发安全
+type PageStateStore struct {
+	dir string
+
+	mu    sync.Mutex
+	hosts map[string]map[string]*PageState
+	dirty map[string]bool
+}
+
+func NewPageStateStore(dir string) (*PageStateStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &PageStateStore{
+		dir:   dir,
+		hosts: map[string]map[string]*PageState{},
+		dirty: map[string]bool{},
+	}, nil
+}
+
+// Get 页面上次采集的状态
+func (s *PageStateStore) Get(pageURL string) (PageState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state := s.host(pageURL)[pageURL]; state != nil {
+		return *state, true
+	}
+	return PageState{}, false
+}
+
+// Put 记录页面状态
+func (s *PageStateStore) Put(state PageState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.host(state.URL)[state.URL] = &state
+	s.dirty[hostKey(state.URL)] = true
+}
+
+// Touch 页面未变化（304），只更新确认时间
+func (s *PageStateStore) Touch(pageURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state := s.host(pageURL)[pageURL]; state != nil {
+		state.CheckedAt = time.Now()
+		s.dirty[hostKey(pageURL)] = true
+	}
+}
+
+// Flush 将有变化的站点写入磁盘
+func (s *PageStateStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for key := range s.dirty {
+		data, err := json.Marshal(s.hosts[key])
+		if err == nil {
+			tmp := s.path(key) + ".tmp"
+			if err = os.WriteFile(tmp, data, 0644); err == nil {
+				err = os.Rename(tmp, s.path(key))
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(s.dirty, key)
+	}
+	return errors.Join(errs...)
+}
+
+// host 站点的页面状态，首次访问时从磁盘加载（调用方持有锁）
+func (s *PageStateStore) host(pageURL string) map[string]*PageState {
+	key := hostKey(pageURL)
+	states := s.hosts[key]
+	if states != nil {
+		return states
+	}
+	states = map[string]*PageState{}
+	if data, err := os.ReadFile(s.path(key)); err == nil {
+		_ = json.Unmarshal(data, &states)
+	}
+	s.hosts[key] = states
+	return states
+}
+
+func (s *PageStateStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// hostKey 站点文件名，端口中的冒号替换为下划线
+func hostKey(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return "_"
+	}
+	return strings.ReplaceAll(strings.ToLower(u.Host), ":", "_")
+}
@@ -0,0 +1,98 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryDocumentSink struct {
+	mu   sync.Mutex
+	docs []*Document
+}
+
+func (s *memoryDocumentSink) Save(doc *Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs = append(s.docs, doc)
+	return nil
+}
+
+func TestIncrementalCrawl(t *testing.T) {
+	article := func(title string) string {
+		return fmt.Sprintf(`<html><head><title>%s</title></head><body><div class="content"><h1>%s</h1><p>%s</p></div></body></html>`,
+			title, title, strings.Repeat("为进一步做好安全生产工作，现将有关事项通知如下。", 10))
+	}
+	revision := "v1"
+	var conditional int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><a href="/a.html">通知一</a><a href="/b.html">通知二</a></body></html>`)
+	})
+	mux.HandleFunc("/a.html", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"a1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("ETag", `"a1"`)
+		fmt.Fprint(w, article("关于开展安全生产检查的通知"))
+	})
+	mux.HandleFunc("/b.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, article("关于调整办公时间的通知"+revision))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	states, err := NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoints, err := NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &memoryDocumentSink{}
+	spider := NewSpider(nil, nil, NewRobotsCache("", 0), checkpoints, states, sink, nil, zap.NewNop())
+	crawl := func(mode CrawlMode) *Task {
+		task := NewTask(server.URL+"/", Options{MaxDepth: 2, Mode: mode, Politeness: PolitenessOptions{DelayMs: 1}})
+		if err := spider.Start(context.Background(), task); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	first := crawl(CrawlFull)
+	if first.Changes.New != 3 || len(sink.docs) != 2 {
+		t.Fatalf("full crawl: changes = %+v, docs = %d", first.Changes, len(sink.docs))
+	}
+	// 两篇通知正文相同，后采集的标记为重复
+	if first.Duplicates.Count() != 1 || (sink.docs[0].DuplicateOf == "") == (sink.docs[1].DuplicateOf == "") {
+		t.Errorf("duplicates = %+v", first.Duplicates.Clusters())
+	}
+
+	// a.html 返回 304，b.html 内容变化
+	sink.docs = nil
+	revision = "v2"
+	task := crawl(CrawlIncremental)
+	if conditional != 1 || task.Changes.NotModified != 1 || task.Changes.Changed != 1 {
+		t.Errorf("incremental crawl: conditional = %d, changes = %+v", conditional, task.Changes)
+	}
+	if len(sink.docs) != 1 || !strings.HasSuffix(sink.docs[0].URL, "/b.html") || sink.docs[0].Change != ChangeChanged {
+		t.Errorf("incremental docs = %+v", sink.docs)
+	}
+
+	// 状态已写入磁盘
+	reloaded, _ := NewPageStateStore(states.dir)
+	if state, ok := reloaded.Get(server.URL + "/a.html"); !ok || state.ETag != `"a1"` {
+		t.Errorf("reloaded state = %+v", state)
+	}
+}
@@ -0,0 +1,403 @@
+package crawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 模板归纳
+// 对同一 URL 模式下的多个页面按节点路径对齐 DOM 树：
+// 各页面文本完全相同的节点视为固定模板（导航、版权、分享栏等），文本随页面变化的节点视为内容区域。
+// 在变化区域中选出正文容器、标题和发布时间，正文容器内的固定节点作为 remove 列表
+
+// Page 原始页面
+type Page struct {
+	URL  string
+	HTML string
+}
+
+// InduceOptions 模板归纳参数
+type InduceOptions struct {
+	Name string
+	// URL 正则，为空时由页面地址推断
+	URLPattern string
+	// 节点至少出现在该比例的页面中才参与归纳，默认 0.8
+	MinSupport float64
+}
+
+// pathStat 同一节点路径在各页面中的文本
+type pathStat struct {
+	path  string
+	tag   string
+	texts map[int]string
+	depth int
+}
+
+func (ps *pathStat) stable() bool {
+	var first string
+	for _, text := range ps.texts {
+		if first == "" {
+			first = text
+		} else if text != first {
+			return false
+		}
+	}
+	return first != ""
+}
+
+func (ps *pathStat) avgLen() float64 {
+	if len(ps.texts) == 0 {
+		return 0
+	}
+	total := 0
+	for _, text := range ps.texts {
+		total += utf8.RuneCountInString(text)
+	}
+	return float64(total) / float64(len(ps.texts))
+}
+
+// LoadPages 加载目录下的 html 页面，页面地址依次取同名 json 中的 url、canonical 链接、og:url
+func LoadPages(dir string) ([]Page, error) {
+	var pages []Page
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".html" && ext != ".htm") {
+			return nil
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		page := Page{HTML: string(raw)}
+
+		if golden, err := os.ReadFile(strings.TrimSuffix(path, filepath.Ext(path)) + ".json"); err == nil {
+			var g Golden
+			if err := json.Unmarshal(golden, &g); err == nil {
+				page.URL = g.URL
+			}
+		}
+		if page.URL == "" {
+			if doc, err := goquery.NewDocumentFromReader(strings.NewReader(page.HTML)); err == nil {
+				page.URL = firstNonEmpty(
+					doc.Find(`link[rel="canonical"]`).AttrOr("href", ""),
+					doc.Find(`meta[property="og:url"]`).AttrOr("content", ""),
+				)
+			}
+		}
+
+		pages = append(pages, page)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+var digitsRe = regexp.MustCompile(`\d+`)
+
+// URLPatternOf 将 URL 路径中的数字替换为 \d+ 得到 URL 模式，如 /info/1002/3456.htm -> /info/\d+/\d+\.htm$
+func URLPatternOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	parts := digitsRe.Split(u.Path, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return strings.Join(parts, `\d+`) + "$"
+}
+
+// GroupPages 按域名和 URL 模式对页面分组
+func GroupPages(pages []Page) map[string][]Page {
+	groups := map[string][]Page{}
+	for _, page := range pages {
+		key := ""
+		if u, err := url.Parse(page.URL); err == nil && u.Host != "" {
+			key = u.Hostname() + URLPatternOf(page.URL)
+		}
+		groups[key] = append(groups[key], page)
+	}
+	return groups
+}
+
+// InduceTemplate 从同一 URL 模式的多个页面归纳站点模板
+func InduceTemplate(pages []Page, opts InduceOptions) (*Template, error) {
+	if len(pages) < 2 {
+		return nil, fmt.Errorf("至少需要 2 个页面，当前 %d 个", len(pages))
+	}
+	if opts.MinSupport <= 0 || opts.MinSupport > 1 {
+		opts.MinSupport = 0.8
+	}
+	minPages := int(float64(len(pages))*opts.MinSupport + 0.5)
+	if minPages < 2 {
+		minPages = 2
+	}
+
+	docs := make([]*goquery.Document, 0, len(pages))
+	for _, page := range pages {
+		doc, err := ParseDocument(page.HTML, page.URL)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	stats := map[string]*pathStat{}
+	for i, doc := range docs {
+		walkElements(doc.Find("body"), 0, func(s *goquery.Selection, depth int) {
+			path := nodePath(s)
+			ps := stats[path]
+			if ps == nil {
+				ps = &pathStat{path: path, tag: goquery.NodeName(s), texts: map[int]string{}, depth: depth}
+				stats[path] = ps
+			}
+			ps.texts[i] = normalizeField(s.Text())
+		})
+	}
+
+	var candidates []*pathStat
+	for _, ps := range stats {
+		if len(ps.texts) >= minPages && ps.tag != "body" {
+			candidates = append(candidates, ps)
+		}
+	}
+	// 固定顺序，保证结果可复现
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].path < candidates[j].path })
+
+	title := induceTitle(candidates, docs, minPages)
+	pubTime := induceTime(candidates, minPages)
+	content := induceContent(candidates, title, pubTime)
+	if content == nil {
+		return nil, fmt.Errorf("未找到随页面变化的正文区域")
+	}
+
+	t := &Template{
+		Name:    opts.Name,
+		Content: content.path,
+		Remove:  induceRemove(docs[0], content.path, stats, len(docs)),
+	}
+	if title != nil {
+		t.Title = title.path
+	}
+	if pubTime != nil {
+		t.Time = pubTime.path
+	}
+
+	host, err := induceHost(pages)
+	if err != nil {
+		return nil, err
+	}
+	t.Host = host
+	t.URLPattern = opts.URLPattern
+	if t.URLPattern == "" {
+		t.URLPattern = induceURLPattern(pages)
+	}
+
+	if err := t.Compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// walkElements 深度优先遍历元素节点，跳过脚本样式等无内容节点
+func walkElements(s *goquery.Selection, depth int, fn func(*goquery.Selection, int)) {
+	s.Each(func(i int, el *goquery.Selection) {
+		if skipTags[goquery.NodeName(el)] {
+			return
+		}
+		fn(el, depth)
+		walkElements(el.Children(), depth+1, fn)
+	})
+}
+
+// containerTags 可作为正文容器的标签，段落等叶子块不作为容器，避免只选中正文的一段
+var containerTags = map[string]bool{
+	"div": true, "section": true, "article": true, "main": true, "td": true, "form": true,
+}
+
+// induceContent 变化节点中正文量（去掉标题、日期后的文本量）不低于最大值 80% 的最深节点作为正文容器
+func induceContent(candidates []*pathStat, title *pathStat, pubTime *pathStat) *pathStat {
+	bodyLen := func(ps *pathStat) float64 {
+		total := 0
+		for i, text := range ps.texts {
+			n := utf8.RuneCountInString(text)
+			for _, field := range []*pathStat{title, pubTime} {
+				if field != nil && field != ps && field.texts[i] != "" && strings.Contains(text, field.texts[i]) {
+					n -= utf8.RuneCountInString(field.texts[i])
+				}
+			}
+			total += n
+		}
+		return float64(total) / float64(len(ps.texts))
+	}
+
+	lengths := map[*pathStat]float64{}
+	maxLen := 0.0
+	for _, ps := range candidates {
+		if ps.stable() || !containerTags[ps.tag] {
+			continue
+		}
+		lengths[ps] = bodyLen(ps)
+		if lengths[ps] > maxLen {
+			maxLen = lengths[ps]
+		}
+	}
+
+	var best *pathStat
+	for _, ps := range candidates {
+		n, ok := lengths[ps]
+		if !ok || n <= 0 || n < maxLen*0.8 {
+			continue
+		}
+		if best == nil || ps.depth > best.depth {
+			best = ps
+		}
+	}
+	return best
+}
+
+var headingTags = map[string]bool{"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true}
+
+// induceTitle 文本与页面 <title> 相互包含次数最多的短变化节点，同等情况下优先 h 标签和更深的节点
+func induceTitle(candidates []*pathStat, docs []*goquery.Document, minPages int) *pathStat {
+	titles := make([]string, len(docs))
+	for i, doc := range docs {
+		titles[i] = normalizeField(firstNonEmpty(
+			doc.Find(`meta[property="og:title"]`).AttrOr("content", ""),
+			doc.Find("title").First().Text(),
+		))
+	}
+
+	var best *pathStat
+	bestHits := 0
+	for _, ps := range candidates {
+		if ps.stable() || ps.avgLen() > 120 {
+			continue
+		}
+		hits := 0
+		for i, text := range ps.texts {
+			if text != "" && titles[i] != "" && (strings.Contains(titles[i], text) || strings.Contains(text, titles[i])) {
+				hits++
+			}
+		}
+		if hits < minPages {
+			continue
+		}
+		if best == nil || hits > bestHits ||
+			(hits == bestHits && headingTags[ps.tag] && !headingTags[best.tag]) ||
+			(hits == bestHits && headingTags[ps.tag] == headingTags[best.tag] && ps.depth > best.depth) {
+			best, bestHits = ps, hits
+		}
+	}
+	return best
+}
+
+// induceTime 能解析出日期的文本最短的变化节点
+func induceTime(candidates []*pathStat, minPages int) *pathStat {
+	var best *pathStat
+	for _, ps := range candidates {
+		if ps.stable() || ps.avgLen() > 80 {
+			continue
+		}
+		hits := 0
+		for _, text := range ps.texts {
+			if _, ok := findDate(text); ok {
+				hits++
+			}
+		}
+		if hits < minPages {
+			continue
+		}
+		if best == nil || ps.avgLen() < best.avgLen() || (ps.avgLen() == best.avgLen() && ps.depth > best.depth) {
+			best = ps
+		}
+	}
+	return best
+}
+
+// induceRemove 正文容器内在所有页面中都出现且文本相同的最外层节点
+func induceRemove(doc *goquery.Document, contentPath string, stats map[string]*pathStat, pages int) []string {
+	container := doc.Find(contentPath).First()
+	if container.Length() == 0 {
+		return nil
+	}
+
+	var remove []string
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || skipTags[child.Data] {
+				continue
+			}
+			path := nodePath(goquery.NewDocumentFromNode(child).Selection)
+			if ps := stats[path]; ps != nil && len(ps.texts) == pages && ps.stable() {
+				remove = append(remove, path)
+				continue
+			}
+			visit(child)
+		}
+	}
+	visit(container.Get(0))
+	return remove
+}
+
+// induceHost 所有页面同域名时使用该域名，否则使用共同的主域名通配
+func induceHost(pages []Page) (string, error) {
+	hosts := map[string]bool{}
+	roots := map[string]bool{}
+	for _, page := range pages {
+		u, err := url.Parse(page.URL)
+		if err != nil || u.Hostname() == "" {
+			return "", fmt.Errorf("页面缺少有效地址: %q", page.URL)
+		}
+		hosts[u.Hostname()] = true
+		root, err := extractRootDomain(page.URL)
+		if err != nil {
+			return "", err
+		}
+		roots[root] = true
+	}
+
+	if len(hosts) == 1 {
+		for host := range hosts {
+			return host, nil
+		}
+	}
+	if len(roots) == 1 {
+		for root := range roots {
+			return "*." + root, nil
+		}
+	}
+	return "", fmt.Errorf("页面来自多个站点")
+}
+
+// induceURLPattern 所有页面 URL 模式一致时返回该模式；Template.Match 匹配完整地址，末尾允许带查询参数
+func induceURLPattern(pages []Page) string {
+	pattern := URLPatternOf(pages[0].URL)
+	for _, page := range pages[1:] {
+		if URLPatternOf(page.URL) != pattern {
+			return ""
+		}
+	}
+	if pattern == "" {
+		return ""
+	}
+	return strings.TrimSuffix(pattern, "$") + `(\?.*)?$`
+}
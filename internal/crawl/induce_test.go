@@ -0,0 +1,106 @@
+package crawl
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func inducePage(id int, title string, body string) Page {
+	return Page{
+		URL: fmt.Sprintf("https://www.example.gov.cn/art/2024/3/%d/art_%d.html", id, 100+id),
+		HTML: fmt.Sprintf(`<html><head><title>%s_示例市人民政府</title></head><body>
+		<div class="nav"><a href="/">首页</a><a href="/zwgk">政务公开</a><a href="/hdjl">互动交流</a></div>
+		<div class="main">
+			<div class="article">
+				<h1 class="title">%s</h1>
+				<div class="info"><span class="date">发布日期：2024-03-%02d</span><span>来源：市政府办公室</span></div>
+				<div class="content">%s<div class="share">分享到：微信 微博</div></div>
+			</div>
+			<div class="related"><a href="/a">相关阅读一</a></div>
+		</div>
+		<div class="footer">版权所有：示例市人民政府 备案号：123456</div>
+		</body></html>`, title, title, id, body),
+	}
+}
+
+func TestInduceTemplate(t *testing.T) {
+	// 正文需明显长于标题和日期
+	filler := "<p>" + strings.Repeat("全市各级各部门要提高政治站位，压实工作责任，确保各项任务落到实处。", 3) + "</p>"
+	pages := []Page{
+		inducePage(1, "关于开展春季植树活动的通知", "<p>为进一步改善城市生态环境，市政府决定于三月中旬开展全市春季义务植树活动，请各单位认真组织。</p><p>各单位要加强宣传动员。</p>"+filler),
+		inducePage(2, "市政府召开常务会议研究部署重点工作", "<p>会议听取了一季度经济运行情况汇报，研究部署了下一阶段稳增长、促发展的重点任务。</p>"+filler),
+		inducePage(3, "关于公布第一批行政许可事项清单的公告", "<p>根据有关法律法规规定，现将第一批行政许可事项清单予以公布，自公布之日起施行。</p><p>特此公告。</p>"+filler),
+	}
+
+	// 经 WARC 读写一次，确保记录解析正确
+	var warc strings.Builder
+	for _, page := range pages {
+		response := "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\n\r\n" + page.HTML
+		fmt.Fprintf(&warc, "WARC/1.0\r\nWARC-Type: response\r\nWARC-Target-URI: %s\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n",
+			page.URL, len(response), response)
+	}
+	path := filepath.Join(t.TempDir(), "pages.warc")
+	if err := os.WriteFile(path, []byte(warc.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pages, err := ReadWARC(path)
+	if err != nil || len(pages) != 3 {
+		t.Fatalf("pages = %d, err = %v", len(pages), err)
+	}
+
+	groups := GroupPages(pages)
+	group := groups[`www.example.gov.cn/art/\d+/\d+/\d+/art_\d+\.html$`]
+	if len(groups) != 1 || len(group) != 3 {
+		t.Fatalf("groups = %v", groups)
+	}
+
+	tpl, err := InduceTemplate(group, InduceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpl.Host != "www.example.gov.cn" || tpl.Content != "html > body > div.main:nth-of-type(2) > div.article:nth-of-type(1) > div.content:nth-of-type(2)" {
+		t.Fatalf("host = %s, content = %s", tpl.Host, tpl.Content)
+	}
+	if tpl.Title != "html > body > div.main:nth-of-type(2) > div.article:nth-of-type(1) > h1.title" {
+		t.Errorf("title = %s", tpl.Title)
+	}
+	if tpl.Time != "html > body > div.main:nth-of-type(2) > div.article:nth-of-type(1) > div.info:nth-of-type(1) > span.date:nth-of-type(1)" {
+		t.Errorf("time = %s", tpl.Time)
+	}
+	if len(tpl.Remove) != 1 || !strings.HasSuffix(tpl.Remove[0], "div.share") {
+		t.Errorf("remove = %v", tpl.Remove)
+	}
+
+	store := &TemplateStore{}
+	store.Set([]*Template{tpl})
+	extractor := NewContentExtractor()
+	extractor.Templates = store
+	page := inducePage(4, "关于做好防汛准备工作的通知", "<p>各区县要全面排查风险隐患，落实防汛责任。</p>")
+	content, err := extractor.ExtractFromHTMLWithURL(page.HTML, page.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Template != tpl.Name || content.Title != "关于做好防汛准备工作的通知" || content.Content != "各区县要全面排查风险隐患，落实防汛责任。" {
+		t.Errorf("template = %s, title = %s, content = %q", content.Template, content.Title, content.Content)
+	}
+	if content.PubTime.Day() != 4 {
+		t.Errorf("pub time = %s", content.PubTime)
+	}
+
+	// 归纳出的 URL 模式匹配带查询参数的同类页面，不匹配其他路径
+	for rawURL, want := range map[string]bool{
+		"https://www.example.gov.cn/art/2024/3/5/art_105.html?id=5": true,
+		"https://www.example.gov.cn/art/2024/3/5/art_105.html":      true,
+		"https://www.example.gov.cn/art/2024/3/5/art_105.html.bak":  false,
+		"https://www.example.gov.cn/col/col12/index.html":           false,
+	} {
+		u, _ := url.Parse(rawURL)
+		if got := tpl.Match(u); got != want {
+			t.Errorf("match %s = %v, pattern = %s", rawURL, got, tpl.URLPattern)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package crawl
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 列表页条目抽取
+// 在同一父节点下按「标签 + class」对子元素分组，找出带链接的重复兄弟结构（ul li、.news-item、表格行等），
+// 选择条目数和链接文本长度综合得分最高的一组，逐条抽取标题、链接、日期和摘要
+
+// 列表至少需要的条目数
+const minListItems = 3
+
+// 列表项摘要的候选选择器
+const listSummarySelector = "p, .summary, .desc, .intro, .abstract"
+
+// listGroup 同一父节点下结构相同的兄弟元素
+type listGroup struct {
+	items []*goquery.Selection
+	score float64
+}
+
+// ExtractListItems 找出页面中占主导的重复列表结构并抽取条目，未找到时返回空
+func (ce *ContentExtractor) ExtractListItems(doc *goquery.Document) []ListItem {
+	group := ce.dominantListGroup(doc.Find("body"))
+	if group == nil {
+		return nil
+	}
+
+	base := documentBaseURL(doc)
+	var items []ListItem
+	for _, item := range group.items {
+		if entry, ok := ce.listItem(item, base); ok {
+			items = append(items, entry)
+		}
+	}
+	return items
+}
+
+// dominantListGroup 得分为链接文本总长度（带链接条目数 × 平均链接文本长度），导航菜单等短链接组得分自然较低
+func (ce *ContentExtractor) dominantListGroup(root *goquery.Selection) *listGroup {
+	var best *listGroup
+
+	root.Find("*").AddSelection(root).Each(func(i int, parent *goquery.Selection) {
+		if skipTags[goquery.NodeName(parent)] || parent.Closest(boilerplateSelector).Length() > 0 {
+			return
+		}
+
+		groups := map[string][]*goquery.Selection{}
+		var keys []string
+		parent.Children().Each(func(j int, child *goquery.Selection) {
+			key := listSignature(child)
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], child)
+		})
+
+		for _, key := range keys {
+			members := groups[key]
+			if len(members) < minListItems {
+				continue
+			}
+
+			linked, textLen := 0, 0
+			for _, member := range members {
+				link := listLink(member)
+				if link == nil {
+					continue
+				}
+				linked++
+				textLen += utf8.RuneCountInString(ce.cleanText(link.Text()))
+			}
+			// 大部分条目都要有链接，链接文本平均不少于 4 个字符
+			if linked < minListItems || linked*10 < len(members)*6 || textLen < linked*4 {
+				continue
+			}
+
+			score := float64(textLen)
+			if best == nil || score > best.score {
+				best = &listGroup{items: members, score: score}
+			}
+		}
+	})
+	return best
+}
+
+// listSignature 标签名加排序后的 class
+func listSignature(s *goquery.Selection) string {
+	classes := strings.Fields(s.AttrOr("class", ""))
+	// 交替行、当前项等样式不影响结构
+	filtered := classes[:0]
+	for _, class := range classes {
+		lower := strings.ToLower(class)
+		if lower == "odd" || lower == "even" || lower == "active" || lower == "current" || lower == "first" || lower == "last" {
+			continue
+		}
+		filtered = append(filtered, class)
+	}
+	sort.Strings(filtered)
+	return goquery.NodeName(s) + "." + strings.Join(filtered, ".")
+}
+
+// listLink 条目中文本最长的链接作为标题链接
+func listLink(item *goquery.Selection) *goquery.Selection {
+	var best *goquery.Selection
+	bestLen := 0
+	item.Find("a[href]").AddSelection(item.Filter("a[href]")).Each(func(i int, a *goquery.Selection) {
+		href := strings.TrimSpace(a.AttrOr("href", ""))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return
+		}
+		length := utf8.RuneCountInString(strings.TrimSpace(a.Text()))
+		if title := strings.TrimSpace(a.AttrOr("title", "")); utf8.RuneCountInString(title) > length {
+			length = utf8.RuneCountInString(title)
+		}
+		if best == nil || length > bestLen {
+			best, bestLen = a, length
+		}
+	})
+	return best
+}
+
+// listItem 抽取单个条目，标题被截断（以 ... 结尾）时优先使用 title 属性
+func (ce *ContentExtractor) listItem(item *goquery.Selection, base *url.URL) (ListItem, bool) {
+	link := listLink(item)
+	if link == nil {
+		return ListItem{}, false
+	}
+
+	title := ce.cleanText(link.Text())
+	if attr := ce.cleanText(link.AttrOr("title", "")); attr != "" &&
+		(title == "" || strings.HasSuffix(title, "...") || strings.HasSuffix(title, "…") || strings.HasPrefix(attr, strings.TrimRight(title, ".…"))) {
+		title = attr
+	}
+
+	entry := ListItem{
+		Title: title,
+		URL:   absoluteURL(base, link.AttrOr("href", "")),
+	}
+	if entry.URL == "" {
+		return ListItem{}, false
+	}
+
+	// 日期优先从链接以外的文本中找，避免标题中的年份干扰
+	rest := strings.Replace(ce.cleanText(item.Text()), ce.cleanText(link.Text()), "", 1)
+	if date, ok := findDate(rest); ok {
+		entry.Date = date
+	} else if date, ok := findDate(item.Text()); ok {
+		entry.Date = date
+	}
+
+	item.Find(listSummarySelector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		text := ce.cleanText(s.Text())
+		if text != "" && text != title && s.Find("a[href]").Length() == 0 {
+			if _, isDate := findDate(text); !isDate || utf8.RuneCountInString(text) > 30 {
+				entry.Summary = text
+				return false
+			}
+		}
+		return true
+	})
+
+	return entry, true
+}
@@ -0,0 +1,51 @@
+package crawl
+
+import "testing"
+
+func TestExtractListItems(t *testing.T) {
+	page := `<html><head><title>通知公告</title></head><body>
+	<div class="nav"><ul><li><a href="/">首页</a></li><li><a href="/xwzx">新闻</a></li><li><a href="/tzgg">通知</a></li></ul></div>
+	<div class="list">
+		<ul>
+			<li class="odd"><a href="info/1011/2021.htm" title="关于2024年春季学期开学工作安排的通知">关于2024年春季学期开学工作...</a><span>2024-02-20</span></li>
+			<li class="even"><a href="info/1011/2020.htm">关于做好寒假期间安全工作的通知</a><span>2024年01月12日</span></li>
+			<li class="odd"><a href="/info/1011/2019.htm">2023年度部门决算公开</a><span>[2023/12/30]</span></li>
+			<li class="even"><a href="https://other.example.edu.cn/a.htm">关于开展校园环境整治的通知</a><span>2023-12-01</span></li>
+		</ul>
+	</div>
+	<table class="more"><tr><td><a href="#">更多</a></td></tr></table>
+	</body></html>`
+
+	extractor := NewContentExtractor()
+	content, err := extractor.ExtractFromHTMLWithURL(page, "https://www.example.edu.cn/tzgg/list.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content.ListItems) != 4 {
+		t.Fatalf("items = %+v", content.ListItems)
+	}
+
+	first := content.ListItems[0]
+	if first.Title != "关于2024年春季学期开学工作安排的通知" || first.URL != "https://www.example.edu.cn/tzgg/info/1011/2021.htm" ||
+		first.Date.Format("2006-01-02") != "2024-02-20" {
+		t.Errorf("first = %+v", first)
+	}
+	// 标题中的年份不能当作日期
+	third := content.ListItems[2]
+	if third.URL != "https://www.example.edu.cn/info/1011/2019.htm" || third.Date.Format("2006-01-02") != "2023-12-30" {
+		t.Errorf("third = %+v", third)
+	}
+	if content.ListItems[1].Date.Format("2006-01-02") != "2024-01-12" {
+		t.Errorf("second = %+v", content.ListItems[1])
+	}
+
+	// 详情页不输出列表条目
+	detail := `<html><body><h1>学校召开工作会议</h1><div class="content"><p>会议强调，要统筹推进各项工作。</p></div></body></html>`
+	content, err = extractor.ExtractFromHTMLWithURL(detail, "https://www.example.edu.cn/info/1011/2021.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content.ListItems) != 0 {
+		t.Errorf("detail items = %+v", content.ListItems)
+	}
+}
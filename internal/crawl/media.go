@@ -0,0 +1,215 @@
+package crawl
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Image 正文中的图片
+type Image struct {
+	Src    string `json:"src"`
+	Alt    string `json:"alt,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Attachment 正文中的附件链接
+type Attachment struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+	// 文件类型（扩展名），无法从链接推断时为空，下载时再根据 Content-Type 推断
+	FileType string `json:"file_type,omitempty"`
+}
+
+// Video 正文中嵌入的视频
+type Video struct {
+	Src    string `json:"src"`
+	Poster string `json:"poster,omitempty"`
+	// video / iframe / embed
+	Kind string `json:"kind"`
+}
+
+// attachmentTypes 常见附件扩展名（含 wps/ofd 等政务常用格式）
+var attachmentTypes = map[string]bool{
+	"pdf": true, "doc": true, "docx": true, "xls": true, "xlsx": true, "ppt": true, "pptx": true,
+	"wps": true, "et": true, "dps": true, "ofd": true, "txt": true, "csv": true, "rtf": true,
+	"zip": true, "rar": true, "7z": true, "tar": true, "gz": true,
+}
+
+// attachmentMimeTypes Content-Type 到文件类型的映射（mime 包缺少的 Office 类型）
+var attachmentMimeTypes = map[string]string{
+	"application/pdf":    "pdf",
+	"application/msword": "doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.ms-excel": "xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         "xlsx",
+	"application/vnd.ms-powerpoint":                                             "ppt",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": "pptx",
+	"application/zip":              "zip",
+	"application/x-rar-compressed": "rar",
+	"application/vnd.rar":          "rar",
+	"application/x-7z-compressed":  "7z",
+	"application/ofd":              "ofd",
+}
+
+// 下载类链接：/download.jsp?id=1、/attach/123 等
+var downloadLinkRe = regexp.MustCompile(`(?i)(download|attach|fujian|/fj/)`)
+
+// 静态网页扩展名，/download/news/123.html 这类栏目下的页面不是附件
+var pageExtensions = map[string]bool{"html": true, "htm": true, "shtml": true, "xhtml": true}
+
+// isDownloadLink 路径或查询参数像下载链接，域名不参与判断
+func isDownloadLink(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if pageExtensions[strings.TrimPrefix(strings.ToLower(path.Ext(u.Path)), ".")] {
+		return false
+	}
+	return downloadLinkRe.MatchString(u.Path) || downloadLinkRe.MatchString(u.RawQuery)
+}
+
+var videoHosts = []string{
+	"v.qq.com", "player.bilibili.com", "player.youku.com", "www.ixigua.com", "open.douyin.com",
+	"player.video.iqiyi.com", "www.youtube.com", "player.vimeo.com",
+}
+
+// InferFileType 根据链接扩展名或 Content-Type 推断文件类型
+func InferFileType(rawURL string, contentType string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := strings.TrimPrefix(strings.ToLower(path.Ext(u.Path)), "."); attachmentTypes[ext] {
+			return ext
+		}
+	}
+
+	if contentType == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if fileType, ok := attachmentMimeTypes[mediaType]; ok {
+		return fileType
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return strings.TrimPrefix(exts[0], ".")
+	}
+	return ""
+}
+
+// extractMedia 从正文容器中提取图片、附件和视频，地址基于 base 转为绝对地址
+func (ce *ContentExtractor) extractMedia(container *goquery.Selection, base *url.URL, content *ExtractedContent) {
+	resolve := func(ref string) string {
+		return absoluteURL(base, ref)
+	}
+
+	seen := map[string]bool{}
+
+	container.Find("img").Each(func(i int, s *goquery.Selection) {
+		src := resolve(firstAttr(s, "src", "data-src", "data-original"))
+		if src == "" || seen[src] {
+			return
+		}
+		seen[src] = true
+		content.Images = append(content.Images, Image{
+			Src:    src,
+			Alt:    strings.TrimSpace(s.AttrOr("alt", "")),
+			Width:  dimension(s, "width"),
+			Height: dimension(s, "height"),
+		})
+	})
+
+	container.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href := resolve(s.AttrOr("href", ""))
+		if href == "" || seen[href] {
+			return
+		}
+
+		text := ce.cleanText(s.Text())
+		fileType := InferFileType(href, "")
+		if fileType == "" {
+			// 链接本身没有扩展名时，尝试从链接文字推断（如 "附件1：申报表.docx"）
+			if ext := strings.TrimPrefix(strings.ToLower(path.Ext(text)), "."); attachmentTypes[ext] {
+				fileType = ext
+			} else if !isDownloadLink(href) {
+				return
+			}
+		}
+
+		seen[href] = true
+		content.Attachments = append(content.Attachments, Attachment{
+			Text:     text,
+			URL:      href,
+			FileType: fileType,
+		})
+	})
+
+	container.Find("video, embed, iframe").Each(func(i int, s *goquery.Selection) {
+		kind := goquery.NodeName(s)
+		src := resolve(s.AttrOr("src", ""))
+		if src == "" && kind == "video" {
+			src = resolve(s.Find("source[src]").First().AttrOr("src", ""))
+		}
+		if src == "" || seen[src] || (kind != "video" && !isVideoURL(src)) {
+			return
+		}
+		seen[src] = true
+		content.Videos = append(content.Videos, Video{
+			Src:    src,
+			Poster: resolve(s.AttrOr("poster", "")),
+			Kind:   kind,
+		})
+	})
+}
+
+func isVideoURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".mp4", ".m3u8", ".flv", ".webm", ".mov":
+		return true
+	}
+	for _, host := range videoHosts {
+		if u.Hostname() == host {
+			return true
+		}
+	}
+	return false
+}
+
+func firstAttr(s *goquery.Selection, names ...string) string {
+	for _, name := range names {
+		if value, ok := s.Attr(name); ok && strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+var styleDimensionRe = map[string]*regexp.Regexp{
+	"width":  regexp.MustCompile(`(?i)(?:^|;|\s)width\s*:\s*(\d+)px`),
+	"height": regexp.MustCompile(`(?i)(?:^|;|\s)height\s*:\s*(\d+)px`),
+}
+
+// dimension 从 width/height 属性或内联样式读取像素尺寸
+func dimension(s *goquery.Selection, name string) int {
+	if value, ok := s.Attr(name); ok {
+		if n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "px")); err == nil {
+			return n
+		}
+	}
+	if m := styleDimensionRe[name].FindStringSubmatch(s.AttrOr("style", "")); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
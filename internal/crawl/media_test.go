@@ -0,0 +1,52 @@
+package crawl
+
+import (
+	"testing"
+)
+
+func TestExtractMedia(t *testing.T) {
+	htmlContent := `<html><body>
+	<div class="nav"><a href="/index.htm">首页</a></div>
+	<div class="content">
+		<p>根据工作安排，现将年度项目申报有关事项通知如下，请各单位认真组织申报。</p>
+		<p><img src="/uploads/1.png" alt="流程图" width="640" style="height: 480px"></p>
+		<p>附件1：<a href="/system/_content/download.jsp?id=12">申报书.docx</a></p>
+		<p>附件2：<a href="files/list.xls">项目汇总表</a></p>
+		<p><a href="/news/2.htm">相关新闻</a></p>
+		<p><a href="/download/news/123.html">下载中心通知</a><a href="https://download.example.gov.cn/a/1">下载站</a></p>
+		<p>附件3：<a href="/attach/88">实施细则</a></p>
+		<iframe src="https://player.bilibili.com/player.html?bvid=BV1"></iframe>
+	</div></body></html>`
+
+	content, err := NewContentExtractor().ExtractFromHTMLWithURL(htmlContent, "https://www.example.gov.cn/tzgg/index.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(content.Images) != 1 || content.Images[0].Src != "https://www.example.gov.cn/uploads/1.png" ||
+		content.Images[0].Width != 640 || content.Images[0].Height != 480 {
+		t.Fatalf("unexpected images: %+v", content.Images)
+	}
+
+	expected := []Attachment{
+		{Text: "申报书.docx", URL: "https://www.example.gov.cn/system/_content/download.jsp?id=12", FileType: "docx"},
+		{Text: "项目汇总表", URL: "https://www.example.gov.cn/tzgg/files/list.xls", FileType: "xls"},
+		{Text: "实施细则", URL: "https://www.example.gov.cn/attach/88"},
+	}
+	if len(content.Attachments) != len(expected) {
+		t.Fatalf("unexpected attachments: %+v", content.Attachments)
+	}
+	for i, att := range expected {
+		if content.Attachments[i] != att {
+			t.Errorf("attachment %d = %+v, want %+v", i, content.Attachments[i], att)
+		}
+	}
+
+	if len(content.Videos) != 1 || content.Videos[0].Kind != "iframe" {
+		t.Fatalf("unexpected videos: %+v", content.Videos)
+	}
+
+	if got := InferFileType("https://example.com/download?id=1", "application/vnd.ms-excel; charset=utf-8"); got != "xls" {
+		t.Fatalf("InferFileType = %s", got)
+	}
+}
@@ -0,0 +1,408 @@
+package crawl
+
+import (
+	"encoding/json"
+	"github.com/PuerkitoBio/goquery"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metadata 页面结构化元数据（JSON-LD、OpenGraph、Twitter Card、Dublin Core、Microdata）
+type Metadata struct {
+	Title         string   `json:"title,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Author        string   `json:"author,omitempty"`
+	PublishedTime string   `json:"published_time,omitempty"`
+	ModifiedTime  string   `json:"modified_time,omitempty"`
+	Canonical     string   `json:"canonical,omitempty"`
+	SiteName      string   `json:"site_name,omitempty"`
+	Image         string   `json:"image,omitempty"`
+	Type          string   `json:"type,omitempty"`
+	Keywords      []string `json:"keywords,omitempty"`
+
+	Organization *Organization `json:"organization,omitempty"`
+	Breadcrumbs  []Breadcrumb  `json:"breadcrumbs,omitempty"`
+
+	// 原始键值，便于排查和下游使用
+	OpenGraph  map[string]string `json:"open_graph,omitempty"`
+	Twitter    map[string]string `json:"twitter,omitempty"`
+	DublinCore map[string]string `json:"dublin_core,omitempty"`
+	Microdata  []MicrodataItem   `json:"microdata,omitempty"`
+}
+
+// Organization JSON-LD 中的机构信息
+type Organization struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+	Logo string `json:"logo,omitempty"`
+}
+
+// Breadcrumb 面包屑导航项
+type Breadcrumb struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// MicrodataItem itemscope 元素及其属性（同名属性取第一个值）
+type MicrodataItem struct {
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties"`
+}
+
+// articleTypes 视为文章的 schema.org 类型
+var articleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "Report": true,
+	"ScholarlyArticle": true, "TechArticle": true, "WebPage": true, "AnalysisNewsArticle": true,
+}
+
+// extractMetadata 解析结构化元数据，各来源按可信度从高到低填充：JSON-LD > Microdata > OpenGraph/Twitter > Dublin Core > 普通 meta
+func (ce *ContentExtractor) extractMetadata(doc *goquery.Document) *Metadata {
+	meta := &Metadata{}
+	base := documentBaseURL(doc)
+
+	ce.parseJSONLD(doc, meta)
+	ce.parseMicrodata(doc, meta)
+
+	meta.OpenGraph = metaValues(doc, "property", "og:")
+	for key, value := range metaValues(doc, "property", "article:") {
+		meta.OpenGraph["article:"+key] = value
+	}
+	meta.Twitter = metaValues(doc, "name", "twitter:")
+	meta.DublinCore = metaValues(doc, "name", "dc.")
+	for key, value := range metaValues(doc, "name", "dcterms.") {
+		if _, ok := meta.DublinCore[key]; !ok {
+			meta.DublinCore[key] = value
+		}
+	}
+
+	og, tw, dc := meta.OpenGraph, meta.Twitter, meta.DublinCore
+	// 政府网站常用的 ArticleTitle / PubDate 等 meta 标签
+	plain := func(name string) string {
+		return strings.TrimSpace(doc.Find(`meta[name="`+name+`" i]`).First().AttrOr("content", ""))
+	}
+
+	meta.Title = firstNonEmpty(meta.Title, og["title"], tw["title"], dc["title"], plain("ArticleTitle"))
+	meta.Description = firstNonEmpty(meta.Description, og["description"], tw["description"], dc["description"], plain("description"))
+	meta.Author = firstNonEmpty(meta.Author, og["article:author"], dc["creator"], plain("author"))
+	meta.PublishedTime = firstNonEmpty(meta.PublishedTime, og["article:published_time"], dc["date"], dc["issued"], plain("PubDate"), plain("pubdate"))
+	meta.ModifiedTime = firstNonEmpty(meta.ModifiedTime, og["article:modified_time"], og["updated_time"], dc["modified"])
+	meta.SiteName = firstNonEmpty(meta.SiteName, og["site_name"], plain("SiteName"))
+	meta.Image = firstNonEmpty(meta.Image, og["image"], tw["image"])
+	meta.Type = firstNonEmpty(meta.Type, og["type"])
+
+	canonical := doc.Find(`link[rel="canonical" i]`).First().AttrOr("href", "")
+	meta.Canonical = firstNonEmpty(canonical, meta.Canonical, og["url"])
+	if meta.Canonical != "" {
+		meta.Canonical = absoluteURL(base, meta.Canonical)
+	}
+	if meta.Image != "" {
+		meta.Image = absoluteURL(base, meta.Image)
+	}
+
+	if len(meta.Keywords) == 0 {
+		meta.Keywords = splitKeywords(firstNonEmpty(plain("keywords"), dc["subject"]))
+	}
+
+	return meta
+}
+
+// metaValues 收集指定前缀的 meta 标签，键为去掉前缀后的小写名称
+func metaValues(doc *goquery.Document, attr string, prefix string) map[string]string {
+	values := map[string]string{}
+	doc.Find("meta[" + attr + "]").Each(func(i int, s *goquery.Selection) {
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr(attr, "")))
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		if !strings.HasPrefix(name, prefix) || content == "" {
+			return
+		}
+		key := strings.TrimPrefix(name, prefix)
+		if _, ok := values[key]; !ok {
+			values[key] = content
+		}
+	})
+	return values
+}
+
+// parseJSONLD 解析 <script type="application/ld+json">
+func (ce *ContentExtractor) parseJSONLD(doc *goquery.Document, meta *Metadata) {
+	doc.Find(`script[type="application/ld+json" i]`).Each(func(i int, s *goquery.Selection) {
+		var data any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s.Text())), &data); err != nil {
+			return
+		}
+
+		// WebPage 节点通常只是页面包装，优先使用具体的文章节点
+		nodes := jsonLDNodes(data)
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return !isJSONLDType(nodes[i], "WebPage") && isJSONLDType(nodes[j], "WebPage")
+		})
+		for _, node := range nodes {
+			ce.applyJSONLDNode(node, meta)
+		}
+	})
+}
+
+func isJSONLDType(node map[string]any, typ string) bool {
+	for _, t := range jsonLDStrings(node["@type"]) {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLDNodes 展开数组和 @graph
+func jsonLDNodes(data any) []map[string]any {
+	var nodes []map[string]any
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			nodes = append(nodes, jsonLDNodes(item)...)
+		}
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			nodes = append(nodes, jsonLDNodes(graph)...)
+		}
+		if _, ok := v["@type"]; ok {
+			nodes = append(nodes, v)
+		}
+	}
+	return nodes
+}
+
+func (ce *ContentExtractor) applyJSONLDNode(node map[string]any, meta *Metadata) {
+	for _, typ := range jsonLDStrings(node["@type"]) {
+		switch {
+		case articleTypes[typ]:
+			meta.Type = firstNonEmpty(meta.Type, typ)
+			meta.Title = firstNonEmpty(meta.Title, jsonLDString(node["headline"]), jsonLDString(node["name"]))
+			meta.Description = firstNonEmpty(meta.Description, jsonLDString(node["description"]))
+			meta.Author = firstNonEmpty(meta.Author, strings.Join(jsonLDNames(node["author"]), ", "))
+			meta.PublishedTime = firstNonEmpty(meta.PublishedTime, jsonLDString(node["datePublished"]), jsonLDString(node["dateCreated"]))
+			meta.ModifiedTime = firstNonEmpty(meta.ModifiedTime, jsonLDString(node["dateModified"]))
+			meta.Canonical = firstNonEmpty(meta.Canonical, jsonLDID(node["mainEntityOfPage"]), jsonLDString(node["url"]))
+			meta.Image = firstNonEmpty(meta.Image, jsonLDURL(node["image"]))
+			if len(meta.Keywords) == 0 {
+				meta.Keywords = jsonLDKeywords(node["keywords"])
+			}
+			if publisher := jsonLDNames(node["publisher"]); len(publisher) > 0 {
+				meta.SiteName = firstNonEmpty(meta.SiteName, publisher[0])
+			}
+			if publisher, ok := node["publisher"].(map[string]any); ok {
+				ce.applyJSONLDNode(publisher, meta)
+			}
+		case typ == "BreadcrumbList":
+			meta.Breadcrumbs = jsonLDBreadcrumbs(node["itemListElement"])
+		case typ == "Organization" || typ == "GovernmentOrganization" || typ == "CollegeOrUniversity" || typ == "Hospital":
+			if meta.Organization == nil {
+				meta.Organization = &Organization{
+					Name: jsonLDString(node["name"]),
+					URL:  jsonLDString(node["url"]),
+					Logo: jsonLDURL(node["logo"]),
+				}
+			}
+		}
+	}
+}
+
+func jsonLDBreadcrumbs(v any) []Breadcrumb {
+	items, _ := v.([]any)
+	type positioned struct {
+		position int
+		crumb    Breadcrumb
+	}
+	var list []positioned
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		crumb := Breadcrumb{Name: jsonLDString(m["name"])}
+		switch target := m["item"].(type) {
+		case string:
+			crumb.URL = target
+		case map[string]any:
+			crumb.URL = firstNonEmpty(jsonLDString(target["@id"]), jsonLDString(target["url"]))
+			crumb.Name = firstNonEmpty(crumb.Name, jsonLDString(target["name"]))
+		}
+		position := i + 1
+		if p, ok := m["position"].(float64); ok {
+			position = int(p)
+		} else if p, err := strconv.Atoi(jsonLDString(m["position"])); err == nil {
+			position = p
+		}
+		list = append(list, positioned{position: position, crumb: crumb})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].position < list[j].position })
+	crumbs := make([]Breadcrumb, 0, len(list))
+	for _, p := range list {
+		crumbs = append(crumbs, p.crumb)
+	}
+	return crumbs
+}
+
+// jsonLDString 字符串或数字值
+func jsonLDString(v any) string {
+	switch value := v.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []any:
+		if len(value) > 0 {
+			return jsonLDString(value[0])
+		}
+	}
+	return ""
+}
+
+func jsonLDStrings(v any) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var list []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// jsonLDNames 人员或机构的名称，值可以是字符串、对象或数组
+func jsonLDNames(v any) []string {
+	switch value := v.(type) {
+	case string:
+		if value = strings.TrimSpace(value); value != "" {
+			return []string{value}
+		}
+	case map[string]any:
+		if name := jsonLDString(value["name"]); name != "" {
+			return []string{name}
+		}
+	case []any:
+		var names []string
+		for _, item := range value {
+			names = append(names, jsonLDNames(item)...)
+		}
+		return names
+	}
+	return nil
+}
+
+// jsonLDURL 图片等资源地址，值可以是字符串、ImageObject 或数组
+func jsonLDURL(v any) string {
+	switch value := v.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case map[string]any:
+		return firstNonEmpty(jsonLDString(value["url"]), jsonLDString(value["contentUrl"]), jsonLDString(value["@id"]))
+	case []any:
+		for _, item := range value {
+			if u := jsonLDURL(item); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+func jsonLDID(v any) string {
+	if m, ok := v.(map[string]any); ok {
+		return jsonLDString(m["@id"])
+	}
+	return jsonLDString(v)
+}
+
+func jsonLDKeywords(v any) []string {
+	if list := jsonLDStrings(v); len(list) > 1 {
+		return list
+	}
+	return splitKeywords(jsonLDString(v))
+}
+
+// parseMicrodata 解析 itemscope/itemprop，文章类条目用于补充标题、作者、时间
+func (ce *ContentExtractor) parseMicrodata(doc *goquery.Document, meta *Metadata) {
+	doc.Find("[itemscope][itemtype]").Each(func(i int, scope *goquery.Selection) {
+		itemType := scope.AttrOr("itemtype", "")
+		if idx := strings.LastIndex(itemType, "/"); idx >= 0 {
+			itemType = itemType[idx+1:]
+		}
+
+		item := MicrodataItem{Type: itemType, Properties: map[string]string{}}
+		scope.Find("[itemprop]").Each(func(j int, prop *goquery.Selection) {
+			// 只处理直接属于当前条目的属性
+			if owner := prop.Parent().Closest("[itemscope]"); owner.Length() == 0 || owner.Get(0) != scope.Get(0) {
+				return
+			}
+			value := ce.microdataValue(prop)
+			for _, name := range strings.Fields(prop.AttrOr("itemprop", "")) {
+				if _, ok := item.Properties[name]; !ok && value != "" {
+					item.Properties[name] = value
+				}
+			}
+		})
+		if len(item.Properties) == 0 {
+			return
+		}
+		meta.Microdata = append(meta.Microdata, item)
+
+		if articleTypes[itemType] {
+			p := item.Properties
+			meta.Title = firstNonEmpty(meta.Title, p["headline"], p["name"])
+			meta.Description = firstNonEmpty(meta.Description, p["description"])
+			meta.Author = firstNonEmpty(meta.Author, p["author"])
+			meta.PublishedTime = firstNonEmpty(meta.PublishedTime, p["datePublished"])
+			meta.ModifiedTime = firstNonEmpty(meta.ModifiedTime, p["dateModified"])
+		}
+	})
+}
+
+// microdataValue 按 HTML 规范取属性值；嵌套条目（如作者 Person）取其 name
+func (ce *ContentExtractor) microdataValue(prop *goquery.Selection) string {
+	if _, ok := prop.Attr("itemscope"); ok {
+		return ce.cleanText(prop.Find(`[itemprop="name"]`).First().Text())
+	}
+	if content, ok := prop.Attr("content"); ok {
+		return strings.TrimSpace(content)
+	}
+	switch goquery.NodeName(prop) {
+	case "a", "link", "area":
+		return prop.AttrOr("href", "")
+	case "img", "audio", "video", "source", "iframe", "embed":
+		return prop.AttrOr("src", "")
+	case "time":
+		if datetime, ok := prop.Attr("datetime"); ok {
+			return strings.TrimSpace(datetime)
+		}
+	case "meta":
+		return ""
+	}
+	return ce.cleanText(prop.Text())
+}
+
+func splitKeywords(s string) []string {
+	var keywords []string
+	for _, kw := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '；' || r == '、' || r == '|'
+	}) {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+	return keywords
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package crawl
+
+import (
+	"testing"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	htmlContent := `<html><head>
+	<title>首页 - 某某大学</title>
+	<meta property="og:title" content="OG 标题">
+	<meta property="og:description" content="OG 摘要">
+	<meta name="DC.creator" content="宣传部">
+	<link rel="canonical" href="/info/1001/2002.htm">
+	<script type="application/ld+json">
+	{"@context": "https://schema.org", "@graph": [
+		{"@type": "WebPage", "name": "页面名称"},
+		{"@type": "NewsArticle", "headline": "某某大学举行开学典礼",
+		 "author": [{"@type": "Person", "name": "张三"}, {"@type": "Person", "name": "李四"}],
+		 "datePublished": "2024-09-01T09:30:00+08:00",
+		 "publisher": {"@type": "Organization", "name": "某某大学", "logo": {"@type": "ImageObject", "url": "https://www.example.edu.cn/logo.png"}}},
+		{"@type": "BreadcrumbList", "itemListElement": [
+			{"@type": "ListItem", "position": 2, "name": "学校要闻", "item": "https://www.example.edu.cn/xxyw.htm"},
+			{"@type": "ListItem", "position": 1, "name": "首页", "item": "https://www.example.edu.cn/"}
+		]}
+	]}
+	</script>
+	</head><body><div itemscope itemtype="https://schema.org/Article">
+		<h1 itemprop="headline">Microdata 标题</h1>
+		<time itemprop="datePublished" datetime="2024-08-31">8月31日</time>
+		<p>开学典礼在体育馆隆重举行，全体新生和教师代表参加了典礼，校长发表了热情洋溢的讲话。</p>
+	</div></body></html>`
+
+	content, err := NewContentExtractor().ExtractFromHTMLWithURL(htmlContent, "https://www.example.edu.cn/info/1001/2002.htm?from=home")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if content.Title != "某某大学举行开学典礼" {
+		t.Errorf("title = %s", content.Title)
+	}
+	if content.Author != "张三, 李四" {
+		t.Errorf("author = %s", content.Author)
+	}
+	if content.PubTime.Format("2006-01-02 15:04") != "2024-09-01 09:30" {
+		t.Errorf("pub time = %s", content.PubTime)
+	}
+	if content.Description != "OG 摘要" {
+		t.Errorf("description = %s", content.Description)
+	}
+	if content.Canonical != "https://www.example.edu.cn/info/1001/2002.htm" {
+		t.Errorf("canonical = %s", content.Canonical)
+	}
+
+	meta := content.Metadata
+	if meta.Organization == nil || meta.Organization.Logo != "https://www.example.edu.cn/logo.png" {
+		t.Errorf("organization = %+v", meta.Organization)
+	}
+	if len(meta.Breadcrumbs) != 2 || meta.Breadcrumbs[0].Name != "首页" {
+		t.Errorf("breadcrumbs = %+v", meta.Breadcrumbs)
+	}
+	if meta.DublinCore["creator"] != "宣传部" {
+		t.Errorf("dublin core = %+v", meta.DublinCore)
+	}
+	if len(meta.Microdata) != 1 || meta.Microdata[0].Properties["datePublished"] != "2024-08-31" {
+		t.Errorf("microdata = %+v", meta.Microdata)
+	}
+}
+
+func TestExtractMetaTitle(t *testing.T) {
+	extractor := NewContentExtractor()
+	extractor.TitleSelectors = []string{"[property='og:title']", "title"}
+
+	content, err := extractor.ExtractFromHTML(`<html><head><title>页面标题</title>
+		<meta property="og:title" content="分享标题"></head><body></body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Title != "分享标题" {
+		t.Fatalf("title = %s", content.Title)
+	}
+}
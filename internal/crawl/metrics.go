@@ -0,0 +1,44 @@
+package crawl
+
+import (
+	"time"
+)
+
+// 监控指标
+// 采集过程在请求结束、抽取正文、写入失败时回调 Metrics，由 metrics 包以 Prometheus 指标实现，
+// 未配置时使用空实现
+
+// 写入失败的存储
+const (
+	SinkDocument   = "document"
+	SinkAttachment = "attachment"
+	SinkCheckpoint = "checkpoint"
+	SinkState      = "state"
+)
+
+// Metrics 采集过程的监控回调，需并发安全
+type Metrics interface {
+	// Request 一次请求结束，status 为 0 表示网络错误，304 也经过这里
+	Request(host string, status int, latency time.Duration, size int)
+	// Retry 请求被重新发出
+	Retry(host string)
+	// Extraction 一次正文抽取，err 不为空表示抽取失败
+	Extraction(duration time.Duration, err error)
+	// SinkError 正文、附件、检查点或页面状态写入失败
+	SinkError(sink string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Request(string, int, time.Duration, int) {}
+func (nopMetrics) Retry(string)                            {}
+func (nopMetrics) Extraction(time.Duration, error)         {}
+func (nopMetrics) SinkError(string)                        {}
+
+// orNop 未配置时返回空实现
+func orNop(metrics Metrics) Metrics {
+	if metrics == nil {
+		return nopMetrics{}
+	}
+	return metrics
+}
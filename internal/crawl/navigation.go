@@ -0,0 +1,239 @@
+package crawl
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 导航与栏目结构抽取
+// 高校首页的院系、政务网站的导航栏目是最好的采集入口：
+// 主导航取 nav 或 id/class 含 nav、menu 的区块中同站短链接最多的一个，按嵌套的 ul/li 还原二级菜单；
+// 页脚按列表（ul、dl、select 下拉框）拆分为链接块，块标题取 dt、前置标题或下拉框的首个空选项
+
+// NavItem 栏目，Children 为子栏目
+type NavItem struct {
+	Label    string    `json:"label"`
+	URL      string    `json:"url,omitempty"`
+	Children []NavItem `json:"children,omitempty"`
+}
+
+// Navigation 页面导航结构
+type Navigation struct {
+	Primary []NavItem `json:"primary"`
+	// 页脚链接块，每块的 Children 为块内链接
+	Footer []NavItem `json:"footer,omitempty"`
+}
+
+// 栏目名称的最大长度
+const maxLabelRunes = 16
+
+var (
+	navAttrRe    = regexp.MustCompile(`(?i)nav|menu|daohang|lanmu`)
+	footerAttrRe = regexp.MustCompile(`(?i)foot|bottom|yqlj|friend`)
+	notNavAttrRe = regexp.MustCompile(`(?i)crumb|position|location|foot|bottom|side|page`)
+)
+
+// ExtractNavigation 抽取主导航和页脚链接块，需在移除 nav/footer 等元素之前调用
+func (ce *ContentExtractor) ExtractNavigation(doc *goquery.Document) *Navigation {
+	base := documentBaseURL(doc)
+	nav := &Navigation{}
+
+	if root := ce.primaryNav(doc, base); root != nil {
+		nav.Primary = ce.navItems(root, base)
+	}
+
+	doc.Find("footer, [id], [class]").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) != "footer" && !footerAttrRe.MatchString(s.AttrOr("id", "")+" "+s.AttrOr("class", "")) {
+			return
+		}
+		// 只处理最外层的页脚区块
+		if s.ParentsFiltered("footer").Length() > 0 || s.Parents().FilterFunction(func(j int, p *goquery.Selection) bool {
+			return footerAttrRe.MatchString(p.AttrOr("id", "") + " " + p.AttrOr("class", ""))
+		}).Length() > 0 {
+			return
+		}
+		nav.Footer = append(nav.Footer, ce.footerBlocks(s, base)...)
+	})
+
+	return nav
+}
+
+// Seeds 导航中与页面同一主域名的栏目地址（去重），可作为采集种子
+func (n *Navigation) Seeds(pageURL string) []string {
+	root, _ := extractRootDomain(pageURL)
+	seen := map[string]bool{}
+	var seeds []string
+
+	var walk func(items []NavItem)
+	walk = func(items []NavItem) {
+		for _, item := range items {
+			if item.URL != "" && !seen[item.URL] {
+				if u, err := url.Parse(item.URL); err == nil && root != "" &&
+					(u.Hostname() == root || strings.HasSuffix(u.Hostname(), "."+root)) {
+					seen[item.URL] = true
+					seeds = append(seeds, item.URL)
+				}
+			}
+			walk(item.Children)
+		}
+	}
+	walk(n.Primary)
+	walk(n.Footer)
+	return seeds
+}
+
+// primaryNav 候选区块中同站短链接最多的一个，数量相同时取内层的，再相同取靠前的
+func (ce *ContentExtractor) primaryNav(doc *goquery.Document, base *url.URL) *goquery.Selection {
+	var best *goquery.Selection
+	bestScore := 0
+
+	doc.Find("nav, [id], [class]").Each(func(i int, s *goquery.Selection) {
+		attrs := s.AttrOr("id", "") + " " + s.AttrOr("class", "")
+		if goquery.NodeName(s) != "nav" && !navAttrRe.MatchString(attrs) {
+			return
+		}
+		if notNavAttrRe.MatchString(attrs) || s.Closest("footer").Length() > 0 {
+			return
+		}
+
+		score := 0
+		s.Find("a[href]").Each(func(j int, a *goquery.Selection) {
+			link := absoluteURL(base, a.AttrOr("href", ""))
+			label := ce.cleanText(a.Text())
+			if link != "" && label != "" && utf8.RuneCountInString(label) <= maxLabelRunes && (base == nil || sameHost(base, link)) {
+				score++
+			}
+		})
+		if score >= 3 && (score > bestScore || (score == bestScore && best.HasNodes(s.Get(0)).Length() > 0)) {
+			best, bestScore = s, score
+		}
+	})
+	return best
+}
+
+// navItems 按最外层列表还原菜单树，没有列表结构时返回平铺的链接
+func (ce *ContentExtractor) navItems(root *goquery.Selection, base *url.URL) []NavItem {
+	list := root.Filter("ul, ol")
+	if list.Length() == 0 {
+		list = root.Find("ul, ol").FilterFunction(func(i int, s *goquery.Selection) bool {
+			return s.ParentsUntilSelection(root).Filter("ul, ol").Length() == 0 && s.Children().Filter("li").Length() >= 2
+		}).First()
+	}
+
+	if list.Length() == 0 {
+		return ce.linkItems(root.Find("a"), base)
+	}
+
+	var items []NavItem
+	list.Children().Filter("li").Each(func(i int, li *goquery.Selection) {
+		nested := li.Find("ul, ol").First()
+		own := li.Find("a").FilterFunction(func(j int, a *goquery.Selection) bool {
+			return nested.Length() == 0 || a.ParentsUntilSelection(li).Filter("ul, ol").Length() == 0
+		})
+
+		// 第一个链接在下拉层中时，栏目本身没有链接，名称取下拉层以外的文本
+		dropdown := own.Length() > 0 && own.First().ParentsUntilSelection(li).Filter("div, dl").Length() > 0
+
+		item := NavItem{}
+		if own.Length() > 0 && !dropdown {
+			item.Label = ce.cleanText(firstNonEmpty(own.First().Text(), own.First().AttrOr("title", "")))
+			item.URL = absoluteURL(base, own.First().AttrOr("href", ""))
+			own = own.Slice(1, own.Length())
+		} else {
+			clone := li.Clone()
+			clone.Find("ul, ol, div, dl").Remove()
+			item.Label = ce.cleanText(clone.Text())
+		}
+
+		// 子菜单：嵌套列表，或下拉层中的其余链接
+		if nested.Length() > 0 {
+			item.Children = ce.navItems(nested, base)
+		} else if own.Length() > 0 {
+			item.Children = ce.linkItems(own, base)
+		}
+
+		if item.Label != "" && (item.URL != "" || len(item.Children) > 0) {
+			items = append(items, item)
+		}
+	})
+	return items
+}
+
+// linkItems 平铺的链接，忽略锚点和脚本链接
+func (ce *ContentExtractor) linkItems(links *goquery.Selection, base *url.URL) []NavItem {
+	var items []NavItem
+	seen := map[string]bool{}
+	links.Each(func(i int, a *goquery.Selection) {
+		label := ce.cleanText(firstNonEmpty(a.Text(), a.AttrOr("title", "")))
+		link := absoluteURL(base, a.AttrOr("href", ""))
+		if label == "" || link == "" || seen[link] {
+			return
+		}
+		seen[link] = true
+		items = append(items, NavItem{Label: label, URL: link})
+	})
+	return items
+}
+
+// footerBlocks 将页脚拆分为链接块：每个最外层的 ul/ol/dl/select 为一块，其余零散链接合为一块
+func (ce *ContentExtractor) footerBlocks(root *goquery.Selection, base *url.URL) []NavItem {
+	var blocks []NavItem
+
+	lists := root.Find("ul, ol, dl, select").FilterFunction(func(i int, s *goquery.Selection) bool {
+		return s.ParentsUntilSelection(root).Filter("ul, ol, dl, select").Length() == 0
+	})
+	lists.Each(func(i int, list *goquery.Selection) {
+		block := NavItem{Label: ce.footerBlockLabel(list)}
+
+		if goquery.NodeName(list) == "select" {
+			// 友情链接下拉框，option 的 value 为地址
+			list.Find("option").Each(func(j int, option *goquery.Selection) {
+				link := absoluteURL(base, option.AttrOr("value", ""))
+				label := ce.cleanText(option.Text())
+				if link != "" && label != "" && strings.Contains(link, "://") {
+					block.Children = append(block.Children, NavItem{Label: label, URL: link})
+				}
+			})
+		} else {
+			block.Children = ce.linkItems(list.Find("a"), base)
+		}
+		if len(block.Children) > 0 {
+			blocks = append(blocks, block)
+		}
+	})
+
+	loose := root.Find("a").FilterFunction(func(i int, a *goquery.Selection) bool {
+		return a.ParentsUntilSelection(root).Filter("ul, ol, dl").Length() == 0
+	})
+	if items := ce.linkItems(loose, base); len(items) > 0 {
+		blocks = append(blocks, NavItem{Children: items})
+	}
+	return blocks
+}
+
+// footerBlockLabel 链接块标题：dl 的 dt、select 的空值选项、前一个不含链接的短文本兄弟节点或父节点中的标题
+func (ce *ContentExtractor) footerBlockLabel(list *goquery.Selection) string {
+	switch goquery.NodeName(list) {
+	case "dl":
+		if dt := list.Find("dt").First(); dt.Length() > 0 && dt.Find("a").Length() == 0 {
+			return ce.cleanText(dt.Text())
+		}
+	case "select":
+		if option := list.Find("option").First(); strings.TrimSpace(option.AttrOr("value", "")) == "" {
+			return strings.Trim(ce.cleanText(option.Text()), "-=— ")
+		}
+	}
+
+	if prev := list.PrevAll().First(); prev.Length() > 0 && prev.Find("a").Length() == 0 {
+		if label := ce.cleanText(prev.Text()); label != "" && utf8.RuneCountInString(label) <= maxLabelRunes {
+			return label
+		}
+	}
+	if heading := list.Parent().ChildrenFiltered("h2, h3, h4, h5, .title, .tit").First(); heading.Length() > 0 {
+		return ce.cleanText(heading.Text())
+	}
+	return ""
+}
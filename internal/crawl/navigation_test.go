@@ -0,0 +1,60 @@
+package crawl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractNavigation(t *testing.T) {
+	page := `<html><body>
+	<div class="top"><ul><li><a href="/">设为首页</a></li><li><a href="/en">English</a></li></ul></div>
+	<div class="header-wrap"><div class="main-nav"><ul class="nav">
+		<li><a href="/">首页</a></li>
+		<li><a href="/xxgk.htm">学校概况</a>
+			<ul class="sub"><li><a href="/xxgk/xxjj.htm">学校简介</a></li><li><a href="/xxgk/lrld.htm">历任领导</a></li></ul>
+		</li>
+		<li><span>院系设置</span>
+			<div class="drop"><a href="https://cs.example.edu.cn/">计算机学院</a><a href="https://math.example.edu.cn/">数学学院</a></div>
+		</li>
+		<li><a href="/xwzx.htm">新闻中心</a></li>
+	</ul></div></div>
+	<div class="position"><a href="/">首页</a> > <a href="/xwzx.htm">新闻中心</a> > <a href="#">正文</a></div>
+	<div class="footer">
+		<dl><dt>快速链接</dt><dd><a href="/xxgk.htm">学校概况</a></dd><dd><a href="https://lib.example.edu.cn/">图书馆</a></dd></dl>
+		<select onchange="window.open(this.value)"><option value="">--友情链接--</option><option value="https://www.moe.gov.cn/">教育部</option></select>
+		<p><a href="/lxwm.htm">联系我们</a> 版权所有</p>
+	</div>
+	</body></html>`
+
+	doc, err := ParseDocument(page, "https://www.example.edu.cn/index.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nav := NewContentExtractor().ExtractNavigation(doc)
+
+	want := []NavItem{
+		{Label: "首页", URL: "https://www.example.edu.cn/"},
+		{Label: "学校概况", URL: "https://www.example.edu.cn/xxgk.htm", Children: []NavItem{
+			{Label: "学校简介", URL: "https://www.example.edu.cn/xxgk/xxjj.htm"},
+			{Label: "历任领导", URL: "https://www.example.edu.cn/xxgk/lrld.htm"},
+		}},
+		{Label: "院系设置", Children: []NavItem{
+			{Label: "计算机学院", URL: "https://cs.example.edu.cn/"},
+			{Label: "数学学院", URL: "https://math.example.edu.cn/"},
+		}},
+		{Label: "新闻中心", URL: "https://www.example.edu.cn/xwzx.htm"},
+	}
+	if !reflect.DeepEqual(nav.Primary, want) {
+		t.Errorf("primary = %+v", nav.Primary)
+	}
+
+	if len(nav.Footer) != 3 || nav.Footer[0].Label != "快速链接" || nav.Footer[1].Label != "友情链接" ||
+		nav.Footer[1].Children[0].URL != "https://www.moe.gov.cn/" || nav.Footer[2].Children[0].Label != "联系我们" {
+		t.Errorf("footer = %+v", nav.Footer)
+	}
+
+	seeds := nav.Seeds("https://www.example.edu.cn/index.htm")
+	if len(seeds) != 9 || seeds[4] != "https://cs.example.edu.cn/" {
+		t.Errorf("seeds = %v", seeds)
+	}
+}
@@ -0,0 +1,194 @@
+package crawl
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 站外链接记录
+// 采集范围以外的链接（如公众号文章、微博）不再下探，按目标地址去重记录来源页面和锚文本，
+// 并按域名汇总，知名平台打上标记以便转交专门的采集器
+
+// maxOffsiteLinks 单个任务最多记录的站外链接数，超出后只计数
+const maxOffsiteLinks = 10000
+
+// platformHosts 知名平台域名（含子域名）
+var platformHosts = map[string]string{
+	"mp.weixin.qq.com": "wechat",
+	"weixin.qq.com":    "wechat",
+	"weibo.com":        "weibo",
+	"weibo.cn":         "weibo",
+	"douyin.com":       "douyin",
+	"iesdouyin.com":    "douyin",
+	"bilibili.com":     "bilibili",
+	"b23.tv":           "bilibili",
+}
+
+// OffsiteLink 站外链接，Source/Text 为首次发现时的来源页面和锚文本
+type OffsiteLink struct {
+	URL       string    `json:"url"`
+	Domain    string    `json:"domain"`
+	Platform  string    `json:"platform,omitempty"`
+	Source    string    `json:"source"`
+	Text      string    `json:"text"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// OffsiteDomain 站外域名汇总
+type OffsiteDomain struct {
+	Domain   string `json:"domain"`
+	Platform string `json:"platform,omitempty"`
+	// 出现次数与去重后的链接数
+	Count int `json:"count"`
+	Links int `json:"links"`
+}
+
+// OffsiteLinks 任务内的站外链接记录，并发安全
+type OffsiteLinks struct {
+	mu      sync.Mutex
+	links   map[string]*OffsiteLink
+	order   []string
+	domains map[string]*OffsiteDomain
+}
+
+// OffsiteState 站外链接记录的持久化形式
+type OffsiteState struct {
+	Links   []OffsiteLink   `json:"links"`
+	Domains []OffsiteDomain `json:"domains"`
+}
+
+func NewOffsiteLinks() *OffsiteLinks {
+	return &OffsiteLinks{
+		links:   map[string]*OffsiteLink{},
+		domains: map[string]*OffsiteDomain{},
+	}
+}
+
+// Record 记录一次站外链接
+func (o *OffsiteLinks) Record(source string, target string, text string) {
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		return
+	}
+	domain := strings.ToLower(u.Hostname())
+	platform := PlatformOf(domain)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	d := o.domains[domain]
+	if d == nil {
+		d = &OffsiteDomain{Domain: domain, Platform: platform}
+		o.domains[domain] = d
+	}
+	d.Count++
+
+	if link := o.links[target]; link != nil {
+		link.Count++
+		return
+	}
+	d.Links++
+	if len(o.links) >= maxOffsiteLinks {
+		return
+	}
+	o.links[target] = &OffsiteLink{
+		URL:       target,
+		Domain:    domain,
+		Platform:  platform,
+		Source:    source,
+		Text:      text,
+		Count:     1,
+		FirstSeen: time.Now(),
+	}
+	o.order = append(o.order, target)
+}
+
+// Links 按发现顺序返回站外链接，platform 不为空时只返回该平台的链接
+func (o *OffsiteLinks) Links(platform string) []OffsiteLink {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	links := make([]OffsiteLink, 0, len(o.order))
+	for _, target := range o.order {
+		if link := o.links[target]; platform == "" || link.Platform == platform {
+			links = append(links, *link)
+		}
+	}
+	return links
+}
+
+// Domains 按出现次数从多到少返回域名汇总
+func (o *OffsiteLinks) Domains() []OffsiteDomain {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	domains := make([]OffsiteDomain, 0, len(o.domains))
+	for _, d := range o.domains {
+		domains = append(domains, *d)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].Count != domains[j].Count {
+			return domains[i].Count > domains[j].Count
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+	return domains
+}
+
+// State 全部站外链接及域名汇总，用于写入检查点和任务记录，没有记录时返回 nil
+func (o *OffsiteLinks) State() *OffsiteState {
+	links, domains := o.Links(""), o.Domains()
+	if len(links) == 0 && len(domains) == 0 {
+		return nil
+	}
+	return &OffsiteState{Links: links, Domains: domains}
+}
+
+// Restore 以 State 的结果替换记录内容，state 为 nil 时清空
+func (o *OffsiteLinks) Restore(state *OffsiteState) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.links = map[string]*OffsiteLink{}
+	o.order = nil
+	o.domains = map[string]*OffsiteDomain{}
+	if state == nil {
+		return
+	}
+	for _, link := range state.Links {
+		if o.links[link.URL] != nil {
+			continue
+		}
+		link := link
+		o.links[link.URL] = &link
+		o.order = append(o.order, link.URL)
+	}
+	for _, d := range state.Domains {
+		d := d
+		o.domains[d.Domain] = &d
+	}
+}
+
+// PlatformOf 域名所属的知名平台，不是则返回空
+func PlatformOf(host string) string {
+	host = strings.ToLower(host)
+	for {
+		if platform, ok := platformHosts[host]; ok {
+			return platform
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			return ""
+		}
+		host = host[dot+1:]
+	}
+}
+
+// inScope 域名是否属于采集范围（主域名本身及其子域名）
+func inScope(host string, rootDomain string) bool {
+	host = strings.ToLower(host)
+	return host == rootDomain || strings.HasSuffix(host, "."+rootDomain)
+}
@@ -0,0 +1,65 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOffsiteLinks(t *testing.T) {
+	offsite := NewOffsiteLinks()
+	offsite.Record("https://www.example.edu.cn/", "https://mp.weixin.qq.com/s/abc", "学校公众号")
+	offsite.Record("https://www.example.edu.cn/xwzx.htm", "https://mp.weixin.qq.com/s/abc", "公众号")
+	offsite.Record("https://www.example.edu.cn/", "https://mp.weixin.qq.com/s/def", "招生公众号")
+	offsite.Record("https://www.example.edu.cn/", "https://www.moe.gov.cn/", "教育部")
+
+	domains := offsite.Domains()
+	if len(domains) != 2 || domains[0].Domain != "mp.weixin.qq.com" || domains[0].Platform != "wechat" ||
+		domains[0].Count != 3 || domains[0].Links != 2 {
+		t.Fatalf("domains = %+v", domains)
+	}
+
+	links := offsite.Links("wechat")
+	if len(links) != 2 || links[0].Count != 2 || links[0].Text != "学校公众号" || links[0].Source != "https://www.example.edu.cn/" {
+		t.Errorf("links = %+v", links)
+	}
+
+	if PlatformOf("m.weibo.cn") != "weibo" || PlatformOf("www.bilibili.com") != "bilibili" || PlatformOf("qq.com") != "" {
+		t.Error("platform detection")
+	}
+	if !inScope("news.example.edu.cn", "example.edu.cn") || inScope("badexample.edu.cn", "example.edu.cn") {
+		t.Error("scope detection")
+	}
+}
+
+func TestSpiderOffsiteAtMaxDepth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><a href="/a.html">通知</a><a href="https://mp.weixin.qq.com/s/abc">学校公众号</a></body></html>`)
+	}))
+	defer server.Close()
+
+	checkpoints, err := NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spider := NewSpider(nil, nil, NewRobotsCache("", 0), checkpoints, states, &memoryDocumentSink{}, nil, zap.NewNop())
+	task := NewTask(server.URL+"/", Options{MaxDepth: 1, Politeness: PolitenessOptions{DelayMs: 1}})
+	if err := spider.Start(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	// 首页已是最大深度，站内链接不下探，站外链接照常记录
+	if links := task.Offsite.Links(""); len(links) != 1 || links[0].URL != "https://mp.weixin.qq.com/s/abc" {
+		t.Errorf("offsite links = %+v", links)
+	}
+	if r := task.Report(); r.Requests != 1 {
+		t.Errorf("requests = %d", r.Requests)
+	}
+}
@@ -1,5 +1,10 @@
 package crawl
 
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+)
+
 type PageAnalyzer struct {
 	urlWeight     float64
 	contentWeight float64
@@ -14,31 +19,34 @@ func NewPageAnalyzer() *PageAnalyzer {
 	}
 }
 
-func (pa *PageAnalyzer) IsListPage(pageURL string) (bool, float64, error) {
+func (pa *PageAnalyzer) IsListPage(ctx context.Context, pageURL string) (bool, float64, error) {
+	doc, err := FetchDocument(ctx, pageURL)
+	if err != nil {
+		return false, 0, err
+	}
+
+	isList, totalScore := pa.AnalyzeDocument(doc)
+	return isList, totalScore, nil
+}
+
+// AnalyzeDocument 对已获取的页面判断是否为列表页，doc.Url 为空时跳过 URL 判断
+func (pa *PageAnalyzer) AnalyzeDocument(doc *goquery.Document) (bool, float64) {
 	var totalScore float64
 
 	// URL判断
-	if isListPageByURL(pageURL) {
+	if doc.Url != nil && isListPageByURL(doc.Url.String()) {
 		totalScore += pa.urlWeight
 	}
 
 	// 内容判断
-	isListByContent, err := isListPageByContent(pageURL)
-	if err != nil {
-		return false, 0, err
-	}
-	if isListByContent {
+	if content, err := doc.Html(); err == nil && analyzeHTMLContent(content) {
 		totalScore += pa.contentWeight
 	}
 
 	// DOM判断
-	isListByDOM, err := isListPageByDOM(pageURL)
-	if err != nil {
-		return false, 0, err
-	}
-	if isListByDOM {
+	if analyzeDOM(doc) {
 		totalScore += pa.domWeight
 	}
 
-	return totalScore >= 0.5, totalScore, nil
+	return totalScore >= 0.5, totalScore
 }
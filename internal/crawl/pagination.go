@@ -0,0 +1,235 @@
+package crawl
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 分页识别
+// 支持的翻页地址形式：
+//
+//	index.htm -> index_1.htm / index_2.htm        （TRS 等政府网站常见，createPageHTML 脚本生成）
+//	list.htm  -> list/3.htm / list/2.htm          （博达 VSB 站群，清华等高校站点）
+//	?page=2 / ?p=2 / ?pageNo=2 / ?pn=2
+//
+// 翻页链接可以是普通 href，也可以是 onclick 中的 location.href='...'、goPage(3) 等脚本
+
+// Pagination 分页信息
+type Pagination struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+	// 页码链接，按页码排序
+	Pages []string `json:"pages,omitempty"`
+}
+
+var (
+	nextPageTexts = map[string]bool{"下一页": true, "下页": true, "后一页": true, "next": true, "next page": true, ">": true, "›": true, "»": true, ">>": true}
+	prevPageTexts = map[string]bool{"上一页": true, "上页": true, "前一页": true, "prev": true, "previous": true, "<": true, "‹": true, "«": true, "<<": true}
+
+	// onclick 中直接给出的地址
+	scriptURLRe = regexp.MustCompile(`(?:location(?:\.href)?\s*=|location\.assign\(|location\.replace\(|window\.open\()\s*['"]([^'"]+)['"]`)
+	// onclick 中只有页码的函数调用，如 goPage(3)、turnPage('3')
+	scriptPageRe = regexp.MustCompile(`(?i)(?:go|goto|turn|to|jump)?page\w*\(\s*['"]?(\d+)['"]?\s*\)`)
+	// TRS 分页脚本 createPageHTML(总页数, 当前页(从 0 开始), "index", "html")
+	createPageRe = regexp.MustCompile(`createPageHTML\(\s*(\d+)\s*,\s*(\d+)\s*,\s*['"]([\w-]+)['"]\s*,\s*['"](\w+)['"]`)
+
+	pageSuffixRe = regexp.MustCompile(`^(.*_)(\d+)(\.[a-zA-Z]+)$`)
+	pageDirRe    = regexp.MustCompile(`^(.*/)(\d+)(\.[a-zA-Z]+)$`)
+	pageParams   = []string{"page", "p", "pageNo", "pageno", "pageIndex", "pn", "pageNum", "currentPage"}
+)
+
+// DetectPagination 识别页面中的分页链接，未识别到时返回空
+func (ce *ContentExtractor) DetectPagination(doc *goquery.Document) *Pagination {
+	base := documentBaseURL(doc)
+	if base == nil || doc.Url == nil {
+		return nil
+	}
+	current := doc.Url.String()
+
+	p := &Pagination{}
+	pages := map[int]string{}
+
+	if href, ok := doc.Find(`link[rel="next"]`).Attr("href"); ok {
+		p.Next = absoluteURL(base, href)
+	}
+	if href, ok := doc.Find(`link[rel="prev"]`).Attr("href"); ok {
+		p.Prev = absoluteURL(base, href)
+	}
+
+	// 先收集有明确地址的页码链接，用于推导只有页码的脚本链接
+	type candidate struct {
+		sel    *goquery.Selection
+		text   string
+		link   string
+		number int
+	}
+	var candidates []candidate
+	doc.Find("a, [onclick]").Each(func(i int, s *goquery.Selection) {
+		text := strings.ToLower(ce.cleanText(s.Text()))
+		if text == "" {
+			text = strings.ToLower(strings.TrimSpace(s.AttrOr("value", s.AttrOr("title", ""))))
+		}
+		c := candidate{sel: s, text: text, number: -1}
+		c.link = paginationLink(base, s)
+		if n, err := strconv.Atoi(text); err == nil && n > 0 && n < 10000 {
+			c.number = n
+		}
+		candidates = append(candidates, c)
+	})
+
+	var sample string
+	for _, c := range candidates {
+		if c.number > 0 && c.link != "" && sameHost(base, c.link) {
+			if _, ok := PageNumber(c.link); ok {
+				pages[c.number] = c.link
+				if sample == "" {
+					sample = c.link
+				}
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		link := c.link
+		if link == "" {
+			// 只有页码的脚本调用，按已知页码链接的格式推导地址
+			matches := scriptPageRe.FindStringSubmatch(c.sel.AttrOr("onclick", "") + " " + c.sel.AttrOr("href", ""))
+			if matches == nil {
+				continue
+			}
+			n, _ := strconv.Atoi(matches[1])
+			if link = pageURLFor(firstNonEmpty(sample, base.String()), n); link == "" {
+				continue
+			}
+			if c.number > 0 {
+				pages[c.number] = link
+			}
+		}
+		if !sameHost(base, link) || link == current {
+			continue
+		}
+
+		rel := strings.ToLower(c.sel.AttrOr("rel", ""))
+		class := strings.ToLower(c.sel.AttrOr("class", ""))
+		switch {
+		case p.Next == "" && (rel == "next" || nextPageTexts[c.text] || strings.Contains(class, "next")):
+			p.Next = link
+		case p.Prev == "" && (rel == "prev" || prevPageTexts[c.text] || strings.Contains(class, "prev")):
+			p.Prev = link
+		}
+	}
+
+	ce.createPageLinks(doc, base, p, pages)
+
+	numbers := make([]int, 0, len(pages))
+	for n := range pages {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		if pages[n] != current {
+			p.Pages = append(p.Pages, pages[n])
+		}
+	}
+
+	if p.Next == "" && p.Prev == "" && len(p.Pages) == 0 {
+		return nil
+	}
+	return p
+}
+
+// createPageLinks 解析 TRS createPageHTML 分页脚本
+func (ce *ContentExtractor) createPageLinks(doc *goquery.Document, base *url.URL, p *Pagination, pages map[int]string) {
+	doc.Find("script").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		matches := createPageRe.FindStringSubmatch(s.Text())
+		if matches == nil {
+			return true
+		}
+		total, _ := strconv.Atoi(matches[1])
+		current, _ := strconv.Atoi(matches[2])
+		prefix, ext := matches[3], matches[4]
+
+		// 第 1 页为 index.html，第 n 页为 index_{n-1}.html
+		link := func(index int) string {
+			if index == 0 {
+				return resolveURL(base, prefix+"."+ext)
+			}
+			return resolveURL(base, prefix+"_"+strconv.Itoa(index)+"."+ext)
+		}
+		for index := 0; index < total; index++ {
+			pages[index+1] = link(index)
+		}
+		if p.Next == "" && current+1 < total {
+			p.Next = link(current + 1)
+		}
+		if p.Prev == "" && current > 0 {
+			p.Prev = link(current - 1)
+		}
+		return false
+	})
+}
+
+// paginationLink 元素指向的地址：普通 href，或 onclick / javascript: 中的 location 跳转
+func paginationLink(base *url.URL, s *goquery.Selection) string {
+	if link := absoluteURL(base, s.AttrOr("href", "")); link != "" {
+		return link
+	}
+	script := s.AttrOr("onclick", "") + " " + s.AttrOr("href", "")
+	if matches := scriptURLRe.FindStringSubmatch(script); matches != nil {
+		return absoluteURL(base, matches[1])
+	}
+	return ""
+}
+
+// PageNumber 从翻页地址中识别页码
+func PageNumber(rawURL string) (int, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, false
+	}
+	query := u.Query()
+	for _, param := range pageParams {
+		if n, err := strconv.Atoi(query.Get(param)); err == nil {
+			return n, true
+		}
+	}
+	for _, re := range []*regexp.Regexp{pageSuffixRe, pageDirRe} {
+		if matches := re.FindStringSubmatch(u.Path); matches != nil {
+			n, _ := strconv.Atoi(matches[2])
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// pageURLFor 按 sample 的翻页格式生成第 n 页地址，sample 中没有页码时无法推导
+func pageURLFor(sample string, n int) string {
+	u, err := url.Parse(sample)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	for _, param := range pageParams {
+		if query.Get(param) != "" {
+			query.Set(param, strconv.Itoa(n))
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	for _, re := range []*regexp.Regexp{pageSuffixRe, pageDirRe} {
+		if re.MatchString(u.Path) {
+			u.Path = re.ReplaceAllString(u.Path, "${1}"+strconv.Itoa(n)+"${3}")
+			return u.String()
+		}
+	}
+	return ""
+}
+
+func sameHost(base *url.URL, link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.Hostname() == base.Hostname()
+}
@@ -0,0 +1,65 @@
+package crawl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectPagination(t *testing.T) {
+	cases := []struct {
+		name string
+		url  string
+		html string
+		want Pagination
+	}{
+		{
+			name: "vsb",
+			url:  "https://news.example.edu.cn/xwzx/5.htm",
+			html: `<div class="pb_sys_common"><a href="../xwzx.htm">首页</a><a href="6.htm">上页</a>
+				<a href="6.htm">2</a><span class="p_no_d">3</span><a href="4.htm">4</a>
+				<a href="4.htm" class="Next">下页</a><a href="1.htm">尾页</a></div>`,
+			want: Pagination{
+				Next:  "https://news.example.edu.cn/xwzx/4.htm",
+				Prev:  "https://news.example.edu.cn/xwzx/6.htm",
+				Pages: []string{"https://news.example.edu.cn/xwzx/6.htm", "https://news.example.edu.cn/xwzx/4.htm"},
+			},
+		},
+		{
+			name: "trs",
+			url:  "https://www.example.gov.cn/zwgk/tzgg/index_1.html",
+			html: `<div class="page"><script>createPageHTML(3, 1, "index", "html");</script></div>`,
+			want: Pagination{
+				Next:  "https://www.example.gov.cn/zwgk/tzgg/index_2.html",
+				Prev:  "https://www.example.gov.cn/zwgk/tzgg/index.html",
+				Pages: []string{"https://www.example.gov.cn/zwgk/tzgg/index.html", "https://www.example.gov.cn/zwgk/tzgg/index_2.html"},
+			},
+		},
+		{
+			name: "query and onclick",
+			url:  "https://www.example.com/news?page=1",
+			html: `<ul class="pagination"><li><a href="?page=2">2</a></li>
+				<li onclick="goPage(3)">3</li>
+				<li><a href="javascript:void(0)" onclick="location.href='/news?page=2'">下一页</a></li></ul>`,
+			want: Pagination{
+				Next:  "https://www.example.com/news?page=2",
+				Pages: []string{"https://www.example.com/news?page=2", "https://www.example.com/news?page=3"},
+			},
+		},
+	}
+
+	extractor := NewContentExtractor()
+	for _, c := range cases {
+		doc, err := ParseDocument("<html><body>"+c.html+"</body></html>", c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := extractor.DetectPagination(doc)
+		if got == nil || !reflect.DeepEqual(*got, c.want) {
+			t.Errorf("%s: pagination = %+v", c.name, got)
+		}
+	}
+
+	if n, ok := PageNumber("https://news.example.edu.cn/xwzx/12.htm"); !ok || n != 12 {
+		t.Errorf("page number = %d, %v", n, ok)
+	}
+}
@@ -0,0 +1,275 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 按站点限速
+// 每个站点单独限制并发数和请求间隔，间隔按 AIMD 调整：响应变慢或出错时间隔翻倍，
+// 恢复正常后逐次减少，直至回到初始间隔；连续收到 429/503 时暂停该站点一段时间。
+// robots.txt 的 Crawl-delay 作为该站点间隔的下限
+
+const (
+	DefaultHostParallelism = 4
+	DefaultHostDelay       = 500 * time.Millisecond
+	DefaultHostMaxDelay    = 30 * time.Second
+	DefaultSlowLatency     = 3 * time.Second
+	DefaultPauseAfter      = 3
+	DefaultPauseFor        = 5 * time.Minute
+)
+
+// delayStep 响应正常时每次减少的间隔
+const delayStep = 100 * time.Millisecond
+
+// PolitenessOptions 站点限速配置，为 0 的项使用默认值
+type PolitenessOptions struct {
+	// 单站点并发数
+	Parallelism int `json:"parallelism"`
+	// 初始请求间隔（毫秒）
+	DelayMs int `json:"delayMs"`
+	// 最大请求间隔（毫秒）
+	MaxDelayMs int `json:"maxDelayMs"`
+	// 响应耗时超过该值（毫秒）视为变慢
+	SlowLatencyMs int `json:"slowLatencyMs"`
+	// 连续收到多少次 429/503 后暂停站点
+	PauseAfter int `json:"pauseAfter"`
+	// 暂停时长（秒），响应带 Retry-After 时以其为准
+	PauseSeconds int `json:"pauseSeconds"`
+}
+
+// HostStats 站点请求统计
+type HostStats struct {
+	Host     string `json:"host"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+	// 错误率：网络错误、4xx/5xx 占比
+	ErrorRate    float64    `json:"error_rate"`
+	AvgLatencyMs int64      `json:"avg_latency_ms"`
+	DelayMs      int64      `json:"delay_ms"`
+	InFlight     int        `json:"in_flight"`
+	Pauses       int        `json:"pauses"`
+	PausedUntil  *time.Time `json:"paused_until,omitempty"`
+}
+
+// Politeness 任务内各站点的限速状态，并发安全
+type Politeness struct {
+	parallelism int
+	delay       time.Duration
+	maxDelay    time.Duration
+	slowLatency time.Duration
+	pauseAfter  int
+	pauseFor    time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostThrottle
+}
+
+type hostThrottle struct {
+	// 并发槽位
+	slots chan struct{}
+	// 当前间隔及下一个可用的请求时间
+	delay time.Duration
+	next  time.Time
+
+	requests     int
+	errors       int
+	totalLatency time.Duration
+	// 连续 429/503 次数
+	throttled   int
+	pauses      int
+	pausedUntil time.Time
+}
+
+func NewPoliteness(opts PolitenessOptions) *Politeness {
+	p := &Politeness{
+		parallelism: opts.Parallelism,
+		delay:       time.Duration(opts.DelayMs) * time.Millisecond,
+		maxDelay:    time.Duration(opts.MaxDelayMs) * time.Millisecond,
+		slowLatency: time.Duration(opts.SlowLatencyMs) * time.Millisecond,
+		pauseAfter:  opts.PauseAfter,
+		pauseFor:    time.Duration(opts.PauseSeconds) * time.Second,
+		hosts:       map[string]*hostThrottle{},
+	}
+	if p.parallelism <= 0 {
+		p.parallelism = DefaultHostParallelism
+	}
+	if p.delay <= 0 {
+		p.delay = DefaultHostDelay
+	}
+	if p.maxDelay <= 0 {
+		p.maxDelay = DefaultHostMaxDelay
+	}
+	if p.maxDelay < p.delay {
+		p.maxDelay = p.delay
+	}
+	if p.slowLatency <= 0 {
+		p.slowLatency = DefaultSlowLatency
+	}
+	if p.pauseAfter <= 0 {
+		p.pauseAfter = DefaultPauseAfter
+	}
+	if p.pauseFor <= 0 {
+		p.pauseFor = DefaultPauseFor
+	}
+	return p
+}
+
+func (p *Politeness) host(host string) *hostThrottle {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.hosts[host]
+	if h == nil {
+		h = &hostThrottle{slots: make(chan struct{}, p.parallelism), delay: p.delay}
+		p.hosts[host] = h
+	}
+	return h
+}
+
+// Acquire 等待站点的并发槽位和请求间隔，minDelay 为 robots.txt 声明的 Crawl-delay，ctx 取消时放弃等待。
+// 请求结束后必须调用返回的 done 一次，传入状态码（网络错误时为 0）和响应头
+func (p *Politeness) Acquire(ctx context.Context, host string, minDelay time.Duration) (done func(status int, header http.Header, err error), err error) {
+	h := p.host(host)
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	at := h.next
+	if at.Before(h.pausedUntil) {
+		at = h.pausedUntil
+	}
+	if at.Before(now) {
+		at = now
+	}
+	delay := h.delay
+	if delay < minDelay {
+		delay = minDelay
+	}
+	h.next = at.Add(delay)
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		<-h.slots
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	var once sync.Once
+	return func(status int, header http.Header, err error) {
+		once.Do(func() {
+			p.release(h, time.Since(start), status, header, err)
+			<-h.slots
+		})
+	}, nil
+}
+
+// PausedUntil 站点暂停时返回恢复时间，暂停期间的地址应放回队列而不是在 Acquire 中等待
+func (p *Politeness) PausedUntil(host string) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.hosts[host]
+	if h == nil || !h.pausedUntil.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return h.pausedUntil, true
+}
+
+// release 记录请求结果并按 AIMD 调整间隔
+func (p *Politeness) release(h *hostThrottle, latency time.Duration, status int, header http.Header, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h.requests++
+	h.totalLatency += latency
+	failed := err != nil || status == 0 || status >= http.StatusBadRequest
+	if failed {
+		h.errors++
+	}
+
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		h.throttled++
+		if h.throttled >= p.pauseAfter {
+			h.throttled = 0
+			h.pauses++
+			h.pausedUntil = time.Now().Add(retryAfter(header, p.pauseFor))
+		}
+	} else {
+		h.throttled = 0
+	}
+
+	// 4xx（429 除外）是地址本身的问题，不代表站点压力
+	overloaded := err != nil || status == 0 || status >= http.StatusInternalServerError ||
+		status == http.StatusTooManyRequests || latency > p.slowLatency
+	if overloaded {
+		h.delay *= 2
+		if h.delay > p.maxDelay {
+			h.delay = p.maxDelay
+		}
+	} else if h.delay > p.delay {
+		h.delay -= delayStep
+		if h.delay < p.delay {
+			h.delay = p.delay
+		}
+	}
+}
+
+// Stats 各站点统计，按请求数从多到少
+func (p *Politeness) Stats() []HostStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]HostStats, 0, len(p.hosts))
+	for host, h := range p.hosts {
+		s := HostStats{
+			Host:     host,
+			Requests: h.requests,
+			Errors:   h.errors,
+			DelayMs:  h.delay.Milliseconds(),
+			InFlight: len(h.slots),
+			Pauses:   h.pauses,
+		}
+		if h.requests > 0 {
+			s.ErrorRate = float64(h.errors) / float64(h.requests)
+			s.AvgLatencyMs = (h.totalLatency / time.Duration(h.requests)).Milliseconds()
+		}
+		if h.pausedUntil.After(time.Now()) {
+			pausedUntil := h.pausedUntil
+			s.PausedUntil = &pausedUntil
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Requests != stats[j].Requests {
+			return stats[i].Requests > stats[j].Requests
+		}
+		return stats[i].Host < stats[j].Host
+	})
+	return stats
+}
+
+// retryAfter 解析 Retry-After（秒数或 HTTP 日期），缺失或无效时返回 fallback
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && time.Until(at) > 0 {
+		return time.Until(at)
+	}
+	return fallback
+}
@@ -0,0 +1,124 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoliteness(t *testing.T) {
+	p := NewPoliteness(PolitenessOptions{DelayMs: 1, MaxDelayMs: 8, PauseAfter: 2, PauseSeconds: 60})
+	acquire := func(host string) func(int, http.Header, error) {
+		done, err := p.Acquire(context.Background(), host, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return done
+	}
+
+	// 出错时间隔翻倍，不超过上限
+	for i := 0; i < 5; i++ {
+		acquire("www.example.gov.cn")(0, nil, errors.New("timeout"))
+	}
+	stats := p.Stats()
+	if len(stats) != 1 || stats[0].DelayMs != 8 || stats[0].Errors != 5 || stats[0].ErrorRate != 1 {
+		t.Fatalf("stats after errors = %+v", stats)
+	}
+
+	// 恢复正常后逐次减少，直至回到初始间隔
+	for i := 0; i < 100; i++ {
+		acquire("www.example.gov.cn")(http.StatusOK, nil, nil)
+	}
+	if s := p.Stats()[0]; s.DelayMs != 1 || s.Requests != 105 || s.PausedUntil != nil {
+		t.Errorf("stats after recovery = %+v", s)
+	}
+
+	// 连续 429 后暂停，Retry-After 优先
+	header := http.Header{"Retry-After": []string{"120"}}
+	acquire("news.example.gov.cn")(http.StatusTooManyRequests, header, nil)
+	acquire("news.example.gov.cn")(http.StatusTooManyRequests, header, nil)
+	for _, s := range p.Stats() {
+		if s.Host != "news.example.gov.cn" {
+			continue
+		}
+		if s.Pauses != 1 || s.PausedUntil == nil || time.Until(*s.PausedUntil) < 100*time.Second {
+			t.Errorf("paused stats = %+v", s)
+		}
+	}
+	if until, paused := p.PausedUntil("news.example.gov.cn"); !paused || time.Until(until) < 100*time.Second {
+		t.Errorf("paused until = %v, %v", until, paused)
+	}
+	if _, paused := p.PausedUntil("www.example.gov.cn"); paused {
+		t.Error("unpaused host reported as paused")
+	}
+
+	// 暂停期间的请求在 ctx 取消时放弃等待
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(ctx, "news.example.gov.cn", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire while paused: %v", err)
+	}
+}
+
+// retryMetrics 记录重试次数
+type retryMetrics struct {
+	nopMetrics
+	mu      sync.Mutex
+	retries map[string]int
+}
+
+func (m *retryMetrics) Retry(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[host]++
+}
+
+func TestSpiderRetry(t *testing.T) {
+	var home, down atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			// 首页第一次返回 503，重试后成功
+			if home.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><body><a href="/down.html">通知</a></body></html>`)
+		default:
+			down.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	checkpoints, err := NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := &retryMetrics{retries: map[string]int{}}
+	spider := NewSpider(nil, nil, NewRobotsCache("", 0), checkpoints, states, &memoryDocumentSink{}, metrics, zap.NewNop())
+	task := NewTask(server.URL+"/", Options{MaxDepth: 2, Politeness: PolitenessOptions{DelayMs: 1}})
+	if err := spider.Start(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	// 一直失败的地址最多重试 maxRetries 次
+	if home.Load() != 2 || down.Load() != 1+maxRetries {
+		t.Errorf("requests: home = %d, down = %d", home.Load(), down.Load())
+	}
+	host := server.Listener.Addr().String()
+	if metrics.retries[host] != 1+maxRetries {
+		t.Errorf("retries = %v", metrics.retries)
+	}
+}
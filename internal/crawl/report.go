@@ -0,0 +1,306 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 采集报告
+// 任务运行期间按状态码、内容类型、页面类型、深度统计响应，记录下载字节数、耗时、错误原因和跳过的链接，
+// 结束后连同正文数、重复数、运行时长生成报告。从检查点继续时在原有统计上累加
+
+// 页面类型
+const (
+	PageHome   = "home"
+	PageList   = "list"
+	PageDetail = "detail"
+	// 非 html 或没有正文
+	PageOther = "other"
+)
+
+// 跳过的原因
+const (
+	SkipOffsite  = "offsite"
+	SkipMailto   = "mailto"
+	SkipRobots   = "robots"
+	SkipBudget   = "budget"
+	SkipOversize = "oversize"
+)
+
+// topErrors 报告中保留的错误原因数
+const topErrors = 10
+
+// ErrorCause 错误原因及次数
+type ErrorCause struct {
+	Cause string `json:"cause"`
+	Count int    `json:"count"`
+}
+
+// Report 任务的采集报告
+type Report struct {
+	TaskID     string     `json:"task_id"`
+	URL        string     `json:"url"`
+	Status     TaskStatus `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	// 累计运行时长（秒），不含排队及暂停时间
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// 发出的请求数、成功响应数、失败数
+	Requests int `json:"requests"`
+	Pages    int `json:"pages"`
+	Errors   int `json:"errors"`
+	// 下载字节数及平均响应耗时
+	Bytes        int64   `json:"bytes"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+
+	StatusCodes  map[int]int    `json:"status_codes"`
+	ContentTypes map[string]int `json:"content_types"`
+	PageClasses  map[string]int `json:"page_classes"`
+	Depths       map[int]int    `json:"depths"`
+	TopErrors    []ErrorCause   `json:"top_errors"`
+	// 因站外、robots、预算等原因跳过的链接数
+	Skipped map[string]int `json:"skipped"`
+
+	Documents  int         `json:"documents"`
+	Duplicates int         `json:"duplicates"`
+	Changes    ChangeStats `json:"changes"`
+}
+
+// CrawlStats 任务运行期间的统计，并发安全
+type CrawlStats struct {
+	mu           sync.Mutex
+	requests     int
+	pages        int
+	errors       int
+	bytes        int64
+	latency      time.Duration
+	latencyCount int
+	statusCodes  map[int]int
+	contentTypes map[string]int
+	pageClasses  map[string]int
+	depths       map[int]int
+	errorCauses  map[string]int
+	skipped      map[string]int
+	// 此前各次运行的时长及本次开始时间
+	elapsed time.Duration
+	since   time.Time
+	// 重启前的重复数，指纹索引不持久化
+	duplicates int
+}
+
+func NewCrawlStats() *CrawlStats {
+	return &CrawlStats{
+		statusCodes:  map[int]int{},
+		contentTypes: map[string]int{},
+		pageClasses:  map[string]int{},
+		depths:       map[int]int{},
+		errorCauses:  map[string]int{},
+		skipped:      map[string]int{},
+	}
+}
+
+// statsFromReport 由保存的报告恢复统计，报告中只保留了前几个错误原因
+func statsFromReport(r *Report) *CrawlStats {
+	s := NewCrawlStats()
+	if r == nil {
+		return s
+	}
+	s.requests, s.pages, s.errors, s.bytes = r.Requests, r.Pages, r.Errors, r.Bytes
+	s.latencyCount = r.Pages + r.Errors
+	s.latency = time.Duration(r.AvgLatencyMs * float64(s.latencyCount) * float64(time.Millisecond))
+	s.elapsed = time.Duration(r.DurationSeconds * float64(time.Second))
+	s.duplicates = r.Duplicates
+	for k, v := range r.StatusCodes {
+		s.statusCodes[k] = v
+	}
+	for k, v := range r.ContentTypes {
+		s.contentTypes[k] = v
+	}
+	for k, v := range r.PageClasses {
+		s.pageClasses[k] = v
+	}
+	for k, v := range r.Depths {
+		s.depths[k] = v
+	}
+	for _, e := range r.TopErrors {
+		s.errorCauses[e.Cause] = e.Count
+	}
+	for k, v := range r.Skipped {
+		s.skipped[k] = v
+	}
+	return s
+}
+
+// begin 一次运行开始
+func (s *CrawlStats) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = time.Now()
+}
+
+// end 一次运行结束，累计运行时长
+func (s *CrawlStats) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.since.IsZero() {
+		s.elapsed += time.Since(s.since)
+		s.since = time.Time{}
+	}
+}
+
+func (s *CrawlStats) request() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+}
+
+// response 成功的响应
+func (s *CrawlStats) response(status int, contentType string, size int, depth int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages++
+	s.bytes += int64(size)
+	s.statusCodes[status]++
+	s.depths[depth]++
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	if contentType == "" {
+		contentType = "unknown"
+	}
+	s.contentTypes[strings.ToLower(contentType)]++
+	if latency > 0 {
+		s.latency += latency
+		s.latencyCount++
+	}
+}
+
+// failure 失败的请求，304 也经过这里但不计为错误
+func (s *CrawlStats) failure(status int, err error, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status > 0 {
+		s.statusCodes[status]++
+	}
+	if latency > 0 {
+		s.latency += latency
+		s.latencyCount++
+	}
+	if err == nil {
+		return
+	}
+	s.errors++
+	s.errorCauses[errorCause(status, err)]++
+}
+
+func (s *CrawlStats) classify(class string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageClasses[class]++
+}
+
+func (s *CrawlStats) skip(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped[reason]++
+}
+
+// pageClass 页面类型：列表页、首页、有正文的详情页，其余为 other
+func pageClass(content *ExtractedContent, depth int) string {
+	switch {
+	case len(content.ListItems) > 0:
+		return PageList
+	case depth == 1:
+		return PageHome
+	case strings.TrimSpace(content.Content) != "":
+		return PageDetail
+	default:
+		return PageOther
+	}
+}
+
+// errorCause 错误原因：HTTP 错误取状态码，网络错误去掉地址只保留原因
+func errorCause(status int, err error) string {
+	if status > 0 {
+		return fmt.Sprintf("HTTP %d %s", status, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return "timeout"
+		}
+		err = urlErr.Err
+	}
+	msg := err.Error()
+	if i := strings.LastIndex(msg, ": "); i >= 0 {
+		msg = msg[i+2:]
+	}
+	return msg
+}
+
+// Report 生成任务的采集报告，运行中的任务为当前进度
+func (t *Task) Report() *Report {
+	t.mu.RLock()
+	r := &Report{
+		TaskID:     t.ID,
+		URL:        t.URL,
+		Status:     t.Status,
+		StartedAt:  t.StartedAt,
+		FinishedAt: t.FinishedAt,
+		Documents:  t.Documents,
+		Changes:    t.Changes,
+	}
+	t.mu.RUnlock()
+	s := t.Stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.Duplicates = s.duplicates + t.Duplicates.Count()
+	elapsed := s.elapsed
+	if !s.since.IsZero() {
+		elapsed += time.Since(s.since)
+	}
+	r.DurationSeconds = elapsed.Seconds()
+	r.Requests, r.Pages, r.Errors, r.Bytes = s.requests, s.pages, s.errors, s.bytes
+	if s.latencyCount > 0 {
+		r.AvgLatencyMs = float64(s.latency.Milliseconds()) / float64(s.latencyCount)
+	}
+	r.StatusCodes = copyCounts(s.statusCodes)
+	r.ContentTypes = copyCounts(s.contentTypes)
+	r.PageClasses = copyCounts(s.pageClasses)
+	r.Depths = copyCounts(s.depths)
+	r.Skipped = copyCounts(s.skipped)
+
+	r.TopErrors = make([]ErrorCause, 0, len(s.errorCauses))
+	for cause, count := range s.errorCauses {
+		r.TopErrors = append(r.TopErrors, ErrorCause{Cause: cause, Count: count})
+	}
+	sort.Slice(r.TopErrors, func(i, j int) bool {
+		if r.TopErrors[i].Count != r.TopErrors[j].Count {
+			return r.TopErrors[i].Count > r.TopErrors[j].Count
+		}
+		return r.TopErrors[i].Cause < r.TopErrors[j].Cause
+	})
+	if len(r.TopErrors) > topErrors {
+		r.TopErrors = r.TopErrors[:topErrors]
+	}
+	return r
+}
+
+func copyCounts[K comparable](m map[K]int) map[K]int {
+	c := make(map[K]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,96 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestErrorCause(t *testing.T) {
+	for _, c := range []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{404, errors.New("Not Found"), "HTTP 404 Not Found"},
+		{0, &url.Error{Op: "Get", URL: "http://a.example/x", Err: errors.New("dial tcp 10.0.0.1:80: connect: connection refused")}, "connection refused"},
+		{0, fmt.Errorf("request: %w", context.DeadlineExceeded), "timeout"},
+	} {
+		if got := errorCause(c.status, c.err); got != c.want {
+			t.Errorf("errorCause(%d, %v) = %q, want %q", c.status, c.err, got, c.want)
+		}
+	}
+}
+
+func TestCrawlReport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><a href="/a.html">通知</a><a href="/missing.html">失效</a>`+
+			`<a href="/report.pdf">附件</a><a href="mailto:office@example.gov.cn">邮箱</a>`+
+			`<a href="https://other.example.com/">外站</a></body></html>`)
+	})
+	mux.HandleFunc("/a.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><head><title>通知</title></head><body><div class="content"><h1>通知</h1><p>%s</p></div></body></html>`,
+			strings.Repeat("现将有关事项通知如下。", 20))
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.4")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checkpoints, err := NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spider := NewSpider(nil, nil, NewRobotsCache("", 0), checkpoints, states, &memoryDocumentSink{}, nil, zap.NewNop())
+	task := NewTask(server.URL+"/", Options{MaxDepth: 2, Politeness: PolitenessOptions{DelayMs: 1}})
+	if err := spider.Start(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	r := task.Report()
+	if r.Requests != 4 || r.Pages != 3 || r.Errors != 1 {
+		t.Fatalf("requests = %d, pages = %d, errors = %d", r.Requests, r.Pages, r.Errors)
+	}
+	if r.StatusCodes[200] != 3 || r.StatusCodes[404] != 1 || r.ContentTypes["application/pdf"] != 1 {
+		t.Errorf("status codes = %v, content types = %v", r.StatusCodes, r.ContentTypes)
+	}
+	if r.PageClasses[PageHome] != 1 || r.PageClasses[PageDetail] != 1 || r.PageClasses[PageOther] != 1 {
+		t.Errorf("page classes = %v", r.PageClasses)
+	}
+	if r.Depths[1] != 1 || r.Depths[2] != 2 {
+		t.Errorf("depths = %v", r.Depths)
+	}
+	if len(r.TopErrors) != 1 || r.TopErrors[0].Cause != "HTTP 404 Not Found" {
+		t.Errorf("top errors = %+v", r.TopErrors)
+	}
+	if r.Skipped[SkipOffsite] != 1 || r.Skipped[SkipMailto] != 1 {
+		t.Errorf("skipped = %v", r.Skipped)
+	}
+	if r.Bytes == 0 || r.DurationSeconds <= 0 {
+		t.Errorf("bytes = %d, duration = %f", r.Bytes, r.DurationSeconds)
+	}
+	// 重启后由保存的报告恢复统计
+	restored := LoadTask(task.Record())
+	if got := restored.Report(); got.Requests != r.Requests || got.StatusCodes[404] != 1 || got.Duplicates != r.Duplicates {
+		t.Errorf("restored report = %+v", got)
+	}
+}
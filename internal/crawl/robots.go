@@ -0,0 +1,178 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"github.com/temoto/robotstxt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RobotsMode robots.txt 处理方式
+type RobotsMode string
+
+const (
+	// RobotsIgnore 不读取 robots.txt（默认）
+	RobotsIgnore RobotsMode = "ignore"
+	// RobotsRespect 遵守 Disallow 和 Crawl-delay
+	RobotsRespect RobotsMode = "respect"
+	// RobotsAudit 照常采集，但记录 robots.txt 禁止的地址，Crawl-delay 同样生效
+	RobotsAudit RobotsMode = "audit"
+)
+
+const (
+	DefaultUserAgent = "SeedDetect/1.0"
+	DefaultRobotsTTL = 24 * time.Hour
+	// DefaultRobotsRetryTTL 获取失败时暂按不限制处理的时长，之后重新获取
+	DefaultRobotsRetryTTL = time.Minute
+	// robotsFetchTimeout 获取 robots.txt 的超时
+	robotsFetchTimeout = 10 * time.Second
+)
+
+// ParseRobotsMode 空字符串视为 ignore
+func ParseRobotsMode(s string) (RobotsMode, error) {
+	switch mode := RobotsMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return RobotsIgnore, nil
+	case RobotsIgnore, RobotsRespect, RobotsAudit:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("不支持的 robots 模式: %s", s)
+	}
+}
+
+// RobotsCache 按站点缓存 robots.txt，过期后重新获取
+type RobotsCache struct {
+	userAgent string
+	// robots.txt 中匹配 User-agent 使用的产品名
+	agent string
+	ttl   time.Duration
+	// 获取失败（网络错误、读取失败等）时的缓存时长
+	retryTTL time.Duration
+	client   *http.Client
+
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	// 获取完成后关闭，并发请求同一站点时只获取一次
+	ready   chan struct{}
+	data    *robotstxt.RobotsData
+	expires time.Time
+}
+
+func NewRobotsCache(userAgent string, ttl time.Duration) *RobotsCache {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	if ttl <= 0 {
+		ttl = DefaultRobotsTTL
+	}
+	return &RobotsCache{
+		userAgent: userAgent,
+		agent:     robotsAgent(userAgent),
+		ttl:       ttl,
+		retryTTL:  DefaultRobotsRetryTTL,
+		client:    &http.Client{Timeout: robotsFetchTimeout},
+		entries:   map[string]*robotsEntry{},
+	}
+}
+
+// UserAgent 采集请求使用的 User-Agent
+func (rc *RobotsCache) UserAgent() string {
+	return rc.userAgent
+}
+
+// Check 地址是否允许采集，以及该站点对本爬虫声明的 Crawl-delay
+func (rc *RobotsCache) Check(ctx context.Context, u *url.URL) (bool, time.Duration) {
+	data := rc.robots(ctx, u)
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return data.TestAgent(path, rc.agent), data.FindGroup(rc.agent).CrawlDelay
+}
+
+func (rc *RobotsCache) robots(ctx context.Context, u *url.URL) *robotstxt.RobotsData {
+	key := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	entry := rc.entries[key]
+	if entry != nil {
+		select {
+		case <-entry.ready:
+			if time.Now().After(entry.expires) {
+				entry = nil
+			}
+		default:
+		}
+	}
+	if entry == nil {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		rc.entries[key] = entry
+		rc.mu.Unlock()
+
+		// 缓存各任务共用，不随发起请求的任务取消
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), robotsFetchTimeout)
+		data, ok := rc.fetch(fetchCtx, key+"/robots.txt")
+		cancel()
+		ttl := rc.ttl
+		if !ok {
+			ttl = rc.retryTTL
+		}
+		entry.data = data
+		entry.expires = time.Now().Add(ttl)
+		close(entry.ready)
+		return entry.data
+	}
+	rc.mu.Unlock()
+
+	<-entry.ready
+	return entry.data
+}
+
+// fetch 4xx 不限制，5xx 全部禁止；获取失败或无法解析时视为不限制，ok 为 false
+func (rc *RobotsCache) fetch(ctx context.Context, robotsURL string) (data *robotstxt.RobotsData, ok bool) {
+	allowAll, _ := robotstxt.FromStatusAndBytes(http.StatusNotFound, nil)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return allowAll, false
+	}
+	req.Header.Set("User-Agent", rc.userAgent)
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return allowAll, false
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return allowAll, false
+	}
+	data, err = robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+	if err != nil {
+		return allowAll, false
+	}
+	return data, true
+}
+
+// robotsAgent 从 User-Agent 中取产品名：Mozilla/5.0 (compatible; Foo/1.0) -> Foo，Foo/1.0 -> Foo
+func robotsAgent(userAgent string) string {
+	ua := userAgent
+	if i := strings.Index(ua, "compatible;"); i >= 0 {
+		ua = strings.TrimSpace(ua[i+len("compatible;"):])
+	}
+	if i := strings.IndexAny(ua, "/ ;)"); i > 0 {
+		ua = ua[:i]
+	}
+	return ua
+}
@@ -0,0 +1,78 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotsCache(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&fetches, 1)
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /\n\nUser-agent: SeedDetect\nDisallow: /admin/\nCrawl-delay: 2\n"))
+	}))
+	defer server.Close()
+
+	rc := NewRobotsCache("Mozilla/5.0 (compatible; SeedDetect/1.0)", time.Hour)
+	check := func(path string) (bool, time.Duration) {
+		u, _ := url.Parse(server.URL + path)
+		return rc.Check(context.Background(), u)
+	}
+
+	if allowed, delay := check("/news/1.html"); !allowed || delay != 2*time.Second {
+		t.Errorf("news: allowed = %v, delay = %v", allowed, delay)
+	}
+	if allowed, _ := check("/admin/login"); allowed {
+		t.Error("admin should be disallowed")
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("robots.txt fetched %d times within ttl", n)
+	}
+
+	if mode, err := ParseRobotsMode(""); err != nil || mode != RobotsIgnore {
+		t.Errorf("default mode = %q, %v", mode, err)
+	}
+	if _, err := ParseRobotsMode("obey"); err == nil {
+		t.Error("unknown mode should fail")
+	}
+}
+
+func TestRobotsCacheFailure(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次断开连接，之后正常返回
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /\n"))
+	}))
+	defer server.Close()
+
+	rc := NewRobotsCache("", time.Hour)
+	rc.retryTTL = 0
+	u, _ := url.Parse(server.URL + "/news/1.html")
+
+	// 发起请求的任务已取消，robots.txt 仍然获取
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if allowed, _ := rc.Check(cancelled, u); !allowed {
+		t.Error("failed fetch should allow")
+	}
+	if allowed, _ := rc.Check(context.Background(), u); allowed {
+		t.Error("failed fetch cached for the full ttl")
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("robots.txt fetched %d times", n)
+	}
+}
@@ -0,0 +1,61 @@
+package crawl
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode/utf8"
+)
+
+// 正文少于该字数时不计算指纹
+const minFingerprintRunes = 50
+
+// SimHash 文本的 64 位指纹：汉字逐字、字母数字按词切分，相邻两个词元组成特征
+func SimHash(text string) uint64 {
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return 0
+	}
+	var weights [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(tokens) == 1 {
+		add(tokens[0])
+	}
+	for i := 0; i+1 < len(tokens); i++ {
+		add(tokens[i] + tokens[i+1])
+	}
+
+	var fingerprint uint64
+	for i, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+// HammingDistance 两个指纹不同的位数
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func fingerprintHex(fingerprint uint64) string {
+	return fmt.Sprintf("%016x", fingerprint)
+}
+
+// fingerprintable 正文足够长时才计算指纹
+func fingerprintable(content string) bool {
+	return utf8.RuneCountInString(strings.TrimSpace(content)) >= minFingerprintRunes
+}
@@ -0,0 +1,58 @@
+package crawl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimHash(t *testing.T) {
+	notice := strings.Join([]string{
+		"为进一步做好安全生产工作，切实防范各类事故发生，现将有关事项通知如下。",
+		"一、各单位要高度重视，成立检查工作领导小组，明确责任分工，制定检查方案。",
+		"二、检查范围包括危险化学品、建筑施工、道路交通、消防安全等重点行业领域。",
+		"三、对检查中发现的隐患要建立台账，限期整改，整改情况于月底前报送应急管理局。",
+		"四、请各单位于十月二十日前将联系人名单报送至办公室，联系电话：12345678。",
+	}, "\n")
+	// 转载时常见的小改动：补充来源、改日期
+	mirrored := strings.Replace(notice, "十月二十日", "十月二十五日", 1) + "\n来源：办公室"
+	other := strings.Repeat("根据工作安排，国庆节期间办公时间调整为上午九点至下午五点，请各单位相互转告。", 5)
+
+	if d := HammingDistance(SimHash(notice), SimHash(mirrored)); d > DefaultDuplicateThreshold {
+		t.Errorf("mirrored distance = %d", d)
+	}
+	if d := HammingDistance(SimHash(notice), SimHash(other)); d <= DefaultDuplicateThreshold {
+		t.Errorf("different distance = %d", d)
+	}
+	if SimHash(notice) != SimHash("  "+notice+"\n") {
+		t.Error("whitespace should not change fingerprint")
+	}
+}
+
+func TestDuplicateIndex(t *testing.T) {
+	index := NewDuplicateIndex(3)
+	base := uint64(0xF0F0_1234_5678_9ABC)
+
+	if _, _, dup := index.Check("http://a.gov.cn/1", base); dup {
+		t.Fatal("first page should be canonical")
+	}
+	// 每段各翻转一位，距离 3
+	near := base ^ (1 | 1<<20 | 1<<40)
+	canonical, distance, dup := index.Check("http://a.gov.cn/col2/1", near)
+	if !dup || canonical != "http://a.gov.cn/1" || distance != 3 {
+		t.Fatalf("near: %s %d %v", canonical, distance, dup)
+	}
+	if _, _, dup := index.Check("http://a.gov.cn/2", base^0xFFFF); dup {
+		t.Fatal("distance 16 should not be duplicate")
+	}
+	// 重复检查同一地址不重复计数
+	index.Check("http://a.gov.cn/col2/1", near)
+	index.Check("http://a.gov.cn/1", base)
+
+	clusters := index.Clusters()
+	if index.Count() != 1 || len(clusters) != 1 || len(clusters[0].Members) != 1 {
+		t.Fatalf("clusters = %+v", clusters)
+	}
+	if clusters[0].Fingerprint != "f0f0123456789abc" || clusters[0].Members[0].URL != "http://a.gov.cn/col2/1" {
+		t.Fatalf("cluster = %+v", clusters[0])
+	}
+}
@@ -1,21 +1,62 @@
 package crawl
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly"
 	"go.uber.org/zap"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Spider struct {
 	collyCollector *colly.Collector
+	downloader     *AttachmentDownloader
+	templates      *TemplateStore
+	robots         *RobotsCache
+	checkpoints    *CheckpointStore
+	states         *PageStateStore
+	documents      DocumentSink
+	metrics        Metrics
 	logger         *zap.Logger
 }
 
-func NewSpider(logger *zap.Logger) *Spider {
+// DefaultMaxPages 跟随翻页时单条分页链默认最多采集的页数
+const DefaultMaxPages = 50
+
+// Options 采集任务配置
+type Options struct {
+	MaxDepth int `json:"maxDepth"`
+	// 附件下载
+	Attachments AttachmentOptions `json:"attachments"`
+	// 列表页沿下一页链接翻页，翻页不计入 MaxDepth
+	FollowPagination bool `json:"followPagination"`
+	// 单条分页链最多采集的页数（含第一页），为 0 时使用 DefaultMaxPages
+	MaxPages int `json:"maxPages"`
+	// robots.txt 处理方式，默认忽略
+	Robots RobotsMode `json:"robots"`
+	// 按站点限速
+	Politeness PolitenessOptions `json:"politeness"`
+	// 页数、字节数、时长预算
+	Budget Budget `json:"budget"`
+	// 采集顺序：bfs（默认）、dfs、best
+	Strategy Strategy `json:"strategy"`
+	// 采集模式：full（默认）、incremental
+	Mode CrawlMode `json:"mode"`
+	// 近似重复检测
+	Dedup DedupOptions `json:"dedup"`
+	// 排队优先级：high、normal（默认）、low
+	Priority Priority `json:"priority"`
+}
+
+func NewSpider(downloader *AttachmentDownloader, templates *TemplateStore, robots *RobotsCache, checkpoints *CheckpointStore, states *PageStateStore, documents DocumentSink, metrics Metrics, logger *zap.Logger) *Spider {
 
 	// Redis 去重配置
 	//storage := &redisstorage.Storage{
@@ -33,21 +74,44 @@ func NewSpider(logger *zap.Logger) *Spider {
 	//c.SetStorage(storage)
 
 	return &Spider{
-		logger: logger,
+		downloader:  downloader,
+		templates:   templates,
+		robots:      robots,
+		checkpoints: checkpoints,
+		states:      states,
+		documents:   documents,
+		metrics:     orNop(metrics),
+		logger:      logger,
 	}
 }
 
-func (spider *Spider) Start(target string, maxDepth int) error {
-	logger := spider.logger.Named("Spider Start")
+// Start 执行采集任务，阻塞至采集完成。
+// ctx 取消后不再发出新请求，写入检查点并返回取消原因（context.Cause），之后可通过 task.Resume 从检查点继续
+func (spider *Spider) Start(ctx context.Context, task *Task) error {
+	logger := spider.logger.Named("Spider Start").With(zap.String("task", task.ID))
+	target, opts := task.URL, task.Options
+	task.Stats.begin()
+	defer task.Stats.end()
 	//rePattern := fmt.Sprintf(`^https?://([a-zA-Z0-9-]+\.)*%s(/|$)`, regexp.QuoteMeta(target))
 	//re := regexp.MustCompile(rePattern)
 
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	// 请求顺序由 Frontier 决定，colly 以同步方式在 worker 中执行请求，深度由 Frontier 记录
 	c := colly.NewCollector(
-		colly.Async(true),
-		colly.MaxDepth(maxDepth),
+		colly.UserAgent(spider.robots.UserAgent()),
+		// robots.txt 按任务的 Robots 模式自行处理
 		colly.IgnoreRobotsTxt(),
 		//colly.URLFilters(re),
 	)
+	// 去重由 Frontier 负责，重试时需要再次请求同一地址
+	c.AllowURLRevisit = true
+	if opts.Budget.MaxResponseBytes > 0 {
+		c.MaxBodySize = opts.Budget.MaxResponseBytes
+	}
 	c.WithTransport(&http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   5 * time.Second,
@@ -56,29 +120,96 @@ func (spider *Spider) Start(target string, maxDepth int) error {
 		TLSHandshakeTimeout: 5 * time.Second,
 	})
 
-	// 限流配置
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
-		Parallelism: 100,
-		Delay:       500 * time.Millisecond,
-		RandomDelay: 500 * time.Millisecond,
-	})
-
 	allowDomain, err := extractRootDomain(target)
 	if err != nil {
 		return err
 	}
 
+	crawlCtx := ctx
+	if opts.Budget.DurationSeconds > 0 {
+		var cancel context.CancelFunc
+		crawlCtx, cancel = context.WithTimeout(ctx, time.Duration(opts.Budget.DurationSeconds)*time.Second)
+		defer cancel()
+	}
+	budget := newBudgetTracker(crawlCtx, opts.Budget)
+
+	// 总并发由 worker 数限制，单站点的并发和间隔由 task.Hosts 控制
+	frontier := newDispatcher(NewFrontier(opts.Strategy))
+
+	// 进行中请求的站点槽位，响应或出错时归还；colly 可能对同一请求多次回调，只有首次归还时返回 true
+	inflight := &sync.Map{}
+	release := func(r *colly.Response, err error) bool {
+		done, ok := inflight.LoadAndDelete(r.Request)
+		if !ok {
+			return false
+		}
+		var header http.Header
+		if r.Headers != nil {
+			header = *r.Headers
+		}
+		done.(func(int, http.Header, error))(r.StatusCode, header, err)
+		return true
+	}
+
 	// 请求日志
 	c.OnRequest(func(r *colly.Request) {
 		logger.Info(fmt.Sprintf("🔍 Visiting: %s", r.URL.String()))
 
 		if strings.Contains(r.URL.String(), "mailto:") {
+			task.Stats.skip(SkipMailto)
+			r.Abort()
+			return
+		}
+
+		if !inScope(r.URL.Hostname(), allowDomain) {
+			task.Stats.skip(SkipOffsite)
 			r.Abort()
+			return
+		}
+
+		var crawlDelay time.Duration
+		if opts.Robots == RobotsRespect || opts.Robots == RobotsAudit {
+			allowed, delay := spider.robots.Check(crawlCtx, r.URL)
+			crawlDelay = delay
+			if !allowed {
+				task.RecordRobots(r.URL.String(), opts.Robots == RobotsAudit)
+				if opts.Robots == RobotsRespect {
+					logger.Info(fmt.Sprintf("🤖 robots.txt 禁止，跳过: %s", r.URL.String()))
+					task.Stats.skip(SkipRobots)
+					r.Abort()
+					return
+				}
+				logger.Info(fmt.Sprintf("🤖 robots.txt 禁止，审计模式照常采集: %s", r.URL.String()))
+			}
 		}
 
-		if !strings.HasSuffix(strings.TrimRight(r.URL.String(), "/"), allowDomain) {
+		if !budget.allow(r.URL.Host) {
+			logger.Info(fmt.Sprintf("💰 预算已用尽，跳过: %s", r.URL.String()))
+			task.Stats.skip(SkipBudget)
 			r.Abort()
+			return
+		}
+		done, err := task.Hosts.Acquire(crawlCtx, r.URL.Host, crawlDelay)
+		if err != nil {
+			budget.cancelled()
+			r.Abort()
+			return
+		}
+		r.Ctx.Put(dispatchedKey, "1")
+		r.Ctx.Put(sentAtKey, time.Now())
+		inflight.Store(r, done)
+		task.Stats.request()
+
+		// 增量模式下正文页使用条件请求
+		if opts.Mode == CrawlIncremental {
+			if state, ok := spider.states.Get(r.URL.String()); ok && !state.Hub {
+				if state.ETag != "" {
+					r.Headers.Set("If-None-Match", state.ETag)
+				}
+				if state.LastModified != "" {
+					r.Headers.Set("If-Modified-Since", state.LastModified)
+				}
+			}
 		}
 
 		// 下载器替换（替换为rod）
@@ -86,10 +217,102 @@ func (spider *Spider) Start(target string, maxDepth int) error {
 
 	// 错误日志
 	c.OnError(func(r *colly.Response, err error) {
+		// colly 把 304 当作错误
+		if r.StatusCode == http.StatusNotModified {
+			if release(r, nil) {
+				latency := requestLatency(r.Request)
+				task.Stats.failure(r.StatusCode, nil, latency)
+				spider.metrics.Request(r.Request.URL.Host, r.StatusCode, latency, len(r.Body))
+			}
+			spider.states.Touch(r.Request.URL.String())
+			task.RecordChange(ChangeNotModified)
+			logger.Info(fmt.Sprintf("⏭️ 未修改，跳过: %s", r.Request.URL.String()))
+			return
+		}
+		if release(r, err) {
+			latency := requestLatency(r.Request)
+			task.Stats.failure(r.StatusCode, err, latency)
+			spider.metrics.Request(r.Request.URL.Host, r.StatusCode, latency, len(r.Body))
+		}
+		// 限流、暂时不可用及网络错误由 worker 放回队列重试
+		if r.StatusCode == http.StatusTooManyRequests || r.StatusCode == http.StatusServiceUnavailable ||
+			(r.StatusCode == 0 && crawlCtx.Err() == nil) {
+			r.Ctx.Put(retryKey, "1")
+		}
 		logger.Error(r.Request.URL.String())
 	})
 
+	// 列表页条目的日期，详情页缺少发布时间时使用
+	listDates := &sync.Map{}
+	// 经过翻页到达该页面的次数
+	pageHops := &sync.Map{}
+	hops := func(pageURL string) int {
+		if n, ok := pageHops.Load(pageURL); ok {
+			return n.(int)
+		}
+		return 0
+	}
+
 	c.OnResponse(func(r *colly.Response) {
+		if release(r, nil) {
+			latency := requestLatency(r.Request)
+			task.Stats.response(r.StatusCode, r.Headers.Get("Content-Type"), len(r.Body), requestDepth(r.Request), latency)
+			spider.metrics.Request(r.Request.URL.Host, r.StatusCode, latency, len(r.Body))
+		}
+		budget.addBytes(len(r.Body))
+		if budget.oversize(len(r.Body)) {
+			if opts.Budget.SkipOversize {
+				logger.Info(fmt.Sprintf("✂️ 响应超过 %d 字节，跳过: %s", opts.Budget.MaxResponseBytes, r.Request.URL.String()))
+				task.Stats.skip(SkipOversize)
+				// 清空后 OnHTML 不再从该页面提取链接
+				r.Body = nil
+				return
+			}
+			logger.Info(fmt.Sprintf("✂️ 响应超过 %d 字节，已截断: %s", opts.Budget.MaxResponseBytes, r.Request.URL.String()))
+		}
+		if !isHTMLResponse(r) {
+			task.Stats.classify(PageOther)
+			return
+		}
+		content, err := spider.extractResponse(r)
+		if err != nil {
+			logger.Error(fmt.Sprintf("正文抽取失败: %s", r.Request.URL.String()), zap.Error(err))
+			task.Stats.classify(PageOther)
+			return
+		}
+		task.Stats.classify(pageClass(content, requestDepth(r.Request)))
+
+		pageURL := r.Request.URL.String()
+		if len(content.ListItems) > 0 {
+			logger.Info(fmt.Sprintf("📋 列表页 %s 识别到 %d 条", pageURL, len(content.ListItems)))
+			for _, item := range content.ListItems {
+				if !item.Date.IsZero() {
+					listDates.Store(item.URL, item.Date)
+				}
+			}
+		}
+		if date, ok := listDates.Load(pageURL); ok && content.PubTime.IsZero() {
+			content.PubTime = date.(time.Time)
+		}
+
+		if opts.FollowPagination && len(content.ListItems) > 0 && content.NextPage != "" {
+			if n := hops(pageURL) + 1; n < maxPages {
+				if _, loaded := pageHops.LoadOrStore(content.NextPage, n); !loaded {
+					logger.Info(fmt.Sprintf("📄 翻页 %d: %s", n+1, content.NextPage))
+					// 翻页不增加深度
+					frontier.push(FrontierItem{URL: content.NextPage, Depth: requestDepth(r.Request), Parent: pageURL, Anchor: "下一页"})
+				}
+			} else {
+				logger.Info(fmt.Sprintf("📄 已达最大翻页数 %d: %s", maxPages, pageURL))
+			}
+		}
+
+		if opts.Attachments.Enabled {
+			spider.enqueueAttachments(content, pageURL, opts.Attachments)
+		}
+
+		spider.emitDocument(task, r, content)
+
 		// 如何存储到 s3
 		//url := r.Request.URL.String()
 
@@ -107,27 +330,272 @@ func (spider *Spider) Start(target string, maxDepth int) error {
 	// 处理链接
 	c.OnHTML("a[href]", func(e *colly.HTMLElement) {
 		rawLink := e.Request.AbsoluteURL(e.Attr("href"))
+		// 站外链接只记录不下探，最大深度的页面上的也要记录
+		if u, err := url.Parse(rawLink); err == nil && (u.Scheme == "http" || u.Scheme == "https") && !inScope(u.Hostname(), allowDomain) {
+			task.Offsite.Record(e.Request.URL.String(), rawLink, strings.Join(strings.Fields(e.Text), " "))
+			task.Stats.skip(SkipOffsite)
+			return
+		}
+		depth := requestDepth(e.Request)
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			return
+		}
 		//link := normalizeURL(e.Request.AbsoluteURL(rawLink))
 		//if link == "" {
 		//	return
 		//}
-		err := e.Request.Visit(rawLink)
+		if rawLink == "" || strings.HasPrefix(rawLink, "javascript:") {
+			return
+		}
+		anchor := strings.Join(strings.Fields(e.Text), " ")
+		if !frontier.push(FrontierItem{URL: rawLink, Depth: depth + 1, Parent: e.Request.URL.String(), Anchor: anchor}) {
+			logger.Info(fmt.Sprintf("🟡 已访问，跳过: %s", rawLink))
+		}
+	})
+
+	// 从检查点继续时恢复队列、翻页计数、指纹索引和站外链接，否则从首页开始。
+	// 进程重启后任务记录可能早于检查点，以检查点为准
+	resumed := false
+	if task.Resumes > 0 {
+		if cp, err := spider.checkpoints.Load(task.ID); err == nil {
+			frontier.frontier.Restore(cp.Pending, cp.Seen, cp.Patterns)
+			for pageURL, n := range cp.PageHops {
+				pageHops.Store(pageURL, n)
+			}
+			task.Duplicates.Restore(cp.Duplicates)
+			task.Offsite.Restore(cp.Offsite)
+			resumed = true
+			logger.Info(fmt.Sprintf("♻️ 从检查点继续，待采集 %d 个，已发现 %d 个", len(cp.Pending), len(cp.Seen)))
+		} else {
+			logger.Warn("检查点读取失败，从首页重新采集", zap.Error(err))
+		}
+	}
+	if !resumed {
+		frontier.push(FrontierItem{URL: target, Depth: 1})
+	}
+
+	checkpoint := func(status TaskStatus) {
+		pending, seen, patterns := frontier.snapshot()
+		hopsByPage := map[string]int{}
+		pageHops.Range(func(k, v any) bool {
+			hopsByPage[k.(string)] = v.(int)
+			return true
+		})
+		err := spider.checkpoints.Save(&Checkpoint{
+			TaskID:     task.ID,
+			URL:        task.URL,
+			Options:    opts,
+			Status:     status,
+			CreatedAt:  task.CreatedAt,
+			Pending:    pending,
+			Seen:       seen,
+			PageHops:   hopsByPage,
+			Patterns:   patterns,
+			Duplicates: task.Duplicates.State(),
+			Offsite:    task.Offsite.State(),
+		})
 		if err != nil {
-			if err == colly.ErrAlreadyVisited {
-				logger.Info(fmt.Sprintf("🟡 已访问，跳过: %s", rawLink))
-			} else {
-				logger.Error(fmt.Sprintf("⚠️ 访问失败: %s", rawLink))
+			logger.Error("检查点写入失败", zap.Error(err))
+			spider.metrics.SinkError(SinkCheckpoint)
+		}
+	}
+	stopCheckpoint := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(DefaultCheckpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				checkpoint(TaskRunning)
+				if err := spider.states.Flush(); err != nil {
+					logger.Error("页面状态写入失败", zap.Error(err))
+					spider.metrics.SinkError(SinkState)
+				}
+			case <-stopCheckpoint:
+				return
 			}
 		}
+	}()
 
+	var seedErr error
+	frontier.run(ctx, DefaultWorkers, func(item FrontierItem) {
+		// 站点暂停期间不在 Acquire 中等待，放回队列让 worker 去采集其他站点
+		var host string
+		if u, err := url.Parse(item.URL); err == nil {
+			host = u.Host
+			if until, paused := task.Hosts.PausedUntil(host); paused {
+				frontier.requeueAt(item, until)
+				return
+			}
+		}
+		rctx := colly.NewContext()
+		rctx.Put(depthKey, item.Depth)
+		err := c.Request(http.MethodGet, item.URL, nil, rctx, nil)
+		// 取消时尚未发出的请求放回队列，继续采集时重新请求
+		if ctx.Err() != nil && rctx.Get(dispatchedKey) == "" {
+			frontier.requeue(item)
+			return
+		}
+		// 重试间隔由 task.Hosts 控制：出错后间隔翻倍，连续 429/503 时站点暂停
+		if rctx.Get(retryKey) != "" && item.Retries < maxRetries {
+			item.Retries++
+			spider.metrics.Retry(host)
+			logger.Info(fmt.Sprintf("🔁 第 %d 次重试: %s", item.Retries, item.URL))
+			frontier.requeue(item)
+			return
+		}
+		if err == nil || err == colly.ErrAlreadyVisited {
+			return
+		}
+		if item.URL == target {
+			seedErr = err
+		}
+		logger.Error(fmt.Sprintf("⚠️ 访问失败: %s", item.URL), zap.Error(err))
 	})
+	close(stopCheckpoint)
+	if err := spider.states.Flush(); err != nil {
+		logger.Error("页面状态写入失败", zap.Error(err))
+		spider.metrics.SinkError(SinkState)
+	}
 
-	if err := c.Visit(target); err != nil {
+	if ctx.Err() != nil {
+		cause := context.Cause(ctx)
+		status := TaskCancelled
+		if errors.Is(cause, ErrTaskInterrupted) {
+			status = TaskInterrupted
+		}
+		checkpoint(status)
+		logger.Info(fmt.Sprintf("⏸️ 采集已停止（%s），检查点已保存", status))
+		return cause
+	}
+	if err := spider.checkpoints.Delete(task.ID); err != nil {
+		logger.Error("检查点删除失败", zap.Error(err))
+	}
+	if seedErr != nil {
 		logger.Error("首次访问失败")
-		return err
+		return seedErr
 	}
-	c.Wait()
 	// todo: 当采集完成进行记录
+	if err := budget.err(); err != nil {
+		logger.Info(fmt.Sprintf("💰 %s", err.Error()))
+		return err
+	}
 	logger.Info("✅ 所有采集任务已完成！")
 	return nil
 }
+
+const (
+	// depthKey 请求深度在 colly 上下文中的键，首页为 1
+	depthKey = "depth"
+	// dispatchedKey 请求已通过限速发出
+	dispatchedKey = "dispatched"
+	// sentAtKey 请求发出的时间
+	sentAtKey = "sent_at"
+	// retryKey 请求失败后可以重试
+	retryKey = "retry"
+)
+
+// maxRetries 429/503 及网络错误的最多重试次数
+const maxRetries = 2
+
+func requestDepth(r *colly.Request) int {
+	if depth, ok := r.Ctx.GetAny(depthKey).(int); ok {
+		return depth
+	}
+	return r.Depth
+}
+
+// requestLatency 请求发出到响应或出错的耗时
+func requestLatency(r *colly.Request) time.Duration {
+	if sentAt, ok := r.Ctx.GetAny(sentAtKey).(time.Time); ok {
+		return time.Since(sentAt)
+	}
+	return 0
+}
+
+// emitDocument 记录页面状态并将正文页写入 DocumentSink，增量模式下只写入新增或变化的正文
+func (spider *Spider) emitDocument(task *Task, r *colly.Response, content *ExtractedContent) {
+	pageURL := r.Request.URL.String()
+	hash := genMD5(content.Title + "\n" + content.Content)
+	change := ChangeNew
+	if prev, ok := spider.states.Get(pageURL); ok {
+		change = ChangeChanged
+		if prev.ContentHash == hash {
+			change = ChangeUnchanged
+		}
+	}
+
+	now := time.Now()
+	hub := len(content.ListItems) > 0 || requestDepth(r.Request) == 1
+	spider.states.Put(PageState{
+		URL:          pageURL,
+		ETag:         r.Headers.Get("ETag"),
+		LastModified: r.Headers.Get("Last-Modified"),
+		ContentHash:  hash,
+		Hub:          hub,
+		FetchedAt:    now,
+		CheckedAt:    now,
+	})
+	task.RecordChange(change)
+
+	if hub || strings.TrimSpace(content.Content) == "" {
+		return
+	}
+	doc := &Document{
+		TaskID:           task.ID,
+		URL:              pageURL,
+		Change:           change,
+		ContentHash:      hash,
+		FetchedAt:        now,
+		ExtractedContent: content,
+	}
+	// 未变化的页面也要加入指纹索引，后续页面才能识别为它的重复
+	if !task.Options.Dedup.Disabled && fingerprintable(content.Content) {
+		fingerprint := SimHash(content.Content)
+		doc.SimHash = fingerprintHex(fingerprint)
+		if canonical, distance, ok := task.Duplicates.Check(pageURL, fingerprint); ok {
+			doc.DuplicateOf = canonical
+			doc.Distance = distance
+			spider.logger.Info(fmt.Sprintf("👯 近似重复: %s -> %s（距离 %d）", pageURL, canonical, distance))
+		}
+	}
+	if task.Options.Mode == CrawlIncremental && change == ChangeUnchanged {
+		return
+	}
+	if err := spider.documents.Save(doc); err != nil {
+		spider.logger.Error(fmt.Sprintf("正文写入失败: %s", pageURL), zap.Error(err))
+		spider.metrics.SinkError(SinkDocument)
+		return
+	}
+	task.RecordDocument(pageURL)
+}
+
+// enqueueAttachments 将正文中的附件加入下载队列
+func (spider *Spider) enqueueAttachments(content *ExtractedContent, referer string, opts AttachmentOptions) {
+	for _, att := range content.Attachments {
+		if spider.downloader.Enqueue(att, referer, opts) {
+			spider.logger.Info(fmt.Sprintf("📎 附件入队: %s", att.URL))
+		}
+	}
+}
+
+// extractResponse 对响应重新解析后抽取正文（抽取会修改文档，不能复用 colly 的文档）
+func (spider *Spider) extractResponse(r *colly.Response) (content *ExtractedContent, err error) {
+	start := time.Now()
+	defer func() {
+		spider.metrics.Extraction(time.Since(start), err)
+	}()
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	doc.Url = r.Request.URL
+
+	extractor := NewContentExtractor()
+	extractor.Templates = spider.templates
+	return extractor.ExtractFromDocument(doc)
+}
+
+func isHTMLResponse(r *colly.Response) bool {
+	return strings.Contains(strings.ToLower(r.Headers.Get("Content-Type")), "html")
+}
@@ -1,6 +1,7 @@
 package crawl
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"testing"
@@ -24,7 +25,7 @@ func TestExtract(t *testing.T) {
 	extractor := NewContentExtractor()
 
 	// 从URL提取
-	content, err := extractor.ExtractFromURL("https://www.tsinghua.edu.cn/info/1182/119870.htm")
+	content, err := extractor.ExtractFromURL(context.Background(), "https://www.tsinghua.edu.cn/info/1182/119870.htm")
 
 	// 从HTML字符串提取
 	//htmlContent := `
@@ -101,7 +102,7 @@ func TestPageList(t *testing.T) {
 
 	// 方法3：综合判断
 	analyzer := NewPageAnalyzer()
-	isList, confidence, err := analyzer.IsListPage(url)
+	isList, confidence, err := analyzer.IsListPage(context.Background(), url)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,312 @@
+package crawl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TaskStatus 采集任务状态
+type TaskStatus string
+
+const (
+	TaskPending  TaskStatus = "pending"
+	TaskRunning  TaskStatus = "running"
+	TaskFinished TaskStatus = "finished"
+	TaskFailed   TaskStatus = "failed"
+	// 预算用尽提前结束
+	TaskBudgetExhausted TaskStatus = "budget_exhausted"
+	// 被取消或因进程退出中断，可从检查点继续
+	TaskCancelled   TaskStatus = "cancelled"
+	TaskInterrupted TaskStatus = "interrupted"
+)
+
+// Priority 任务排队的优先级，同一优先级先提交先运行
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// ParsePriority 空字符串视为 normal
+func ParsePriority(s string) (Priority, error) {
+	switch p := Priority(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return PriorityNormal, nil
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("不支持的优先级: %s", s)
+	}
+}
+
+// Level 数值越大越先运行
+func (p Priority) Level() int {
+	switch p {
+	case PriorityHigh:
+		return 1
+	case PriorityLow:
+		return -1
+	default:
+		return 0
+	}
+}
+
+var (
+	ErrTaskCancelled   = errors.New("任务已取消")
+	ErrTaskInterrupted = errors.New("任务被中断")
+)
+
+// Task 一次采集任务
+type Task struct {
+	ID      string  `json:"id"`
+	URL     string  `json:"url"`
+	Options Options `json:"options"`
+
+	mu     sync.RWMutex
+	Status TaskStatus `json:"status"`
+	Error  string     `json:"error,omitempty"`
+	// 用尽的预算
+	BudgetHit  []string  `json:"budget_hit,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// 从检查点继续的次数
+	Resumes int `json:"resumes"`
+	// 在等待队列中的位置（从 1 开始），不在队列中时为 0
+	QueuePosition int `json:"queue_position"`
+
+	// 与上次采集相比的页面变化
+	Changes ChangeStats `json:"changes"`
+	// 写入 DocumentSink 的正文数及地址
+	Documents int `json:"documents"`
+	documents []string
+
+	// robots.txt 禁止采集的地址数
+	RobotsDisallowed int `json:"robots_disallowed"`
+	robots           []RobotsRecord
+
+	// 站外链接记录
+	Offsite *OffsiteLinks `json:"-"`
+	// 各站点限速状态及统计
+	Hosts *Politeness `json:"-"`
+	// 正文指纹索引及重复页面
+	Duplicates *DuplicateIndex `json:"-"`
+	// 采集统计，用于生成报告
+	Stats *CrawlStats `json:"-"`
+}
+
+// maxRobotsRecords 单个任务最多保留的 robots 记录数，超出后只计数
+const maxRobotsRecords = 10000
+
+// RobotsRecord robots.txt 禁止采集的地址
+type RobotsRecord struct {
+	URL string `json:"url"`
+	// audit 模式下照常采集
+	Crawled bool      `json:"crawled"`
+	Time    time.Time `json:"time"`
+}
+
+func NewTask(target string, opts Options) *Task {
+	return &Task{
+		ID:         uuid.NewString(),
+		URL:        target,
+		Options:    opts,
+		Status:     TaskPending,
+		CreatedAt:  time.Now(),
+		Offsite:    NewOffsiteLinks(),
+		Hosts:      NewPoliteness(opts.Politeness),
+		Duplicates: NewDuplicateIndex(opts.Dedup.Threshold),
+		Stats:      NewCrawlStats(),
+	}
+}
+
+// RestoreTask 由检查点重建任务，进程退出前仍在运行的任务标记为中断
+func RestoreTask(cp *Checkpoint) *Task {
+	t := NewTask(cp.URL, cp.Options)
+	t.ID = cp.TaskID
+	t.CreatedAt = cp.CreatedAt
+	t.FinishedAt = cp.SavedAt
+	t.Status = cp.Status
+	if t.Status == TaskRunning || t.Status == TaskPending {
+		t.Status = TaskInterrupted
+	}
+	t.Duplicates.Restore(cp.Duplicates)
+	t.Offsite.Restore(cp.Offsite)
+	return t
+}
+
+// TaskRecord 持久化的任务，含写入 DocumentSink 的正文地址、采集报告、正文指纹索引及站外链接
+type TaskRecord struct {
+	Task       *Task              `json:"task"`
+	Documents  []string           `json:"documents,omitempty"`
+	Report     *Report            `json:"report,omitempty"`
+	Duplicates []DuplicateCluster `json:"duplicates,omitempty"`
+	Offsite    *OffsiteState      `json:"offsite,omitempty"`
+}
+
+// Record 任务的持久化记录
+func (t *Task) Record() *TaskRecord {
+	report := t.Report()
+	duplicates, offsite := t.Duplicates.State(), t.Offsite.State()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return &TaskRecord{
+		Task:       t,
+		Documents:  append([]string(nil), t.documents...),
+		Report:     report,
+		Duplicates: duplicates,
+		Offsite:    offsite,
+	}
+}
+
+// LoadTask 由持久化记录重建任务，进程退出前仍在运行的任务标记为中断
+func LoadTask(record *TaskRecord) *Task {
+	t := record.Task
+	t.Offsite = NewOffsiteLinks()
+	t.Offsite.Restore(record.Offsite)
+	t.Hosts = NewPoliteness(t.Options.Politeness)
+	t.Duplicates = NewDuplicateIndex(t.Options.Dedup.Threshold)
+	t.Duplicates.Restore(record.Duplicates)
+	t.Stats = statsFromReport(record.Report)
+	t.documents = record.Documents
+	t.QueuePosition = 0
+	if t.Status == TaskRunning {
+		t.Status = TaskInterrupted
+	}
+	return t
+}
+
+// Resumable 是否可以从检查点继续
+func (t *Task) Resumable() bool {
+	status := t.GetStatus()
+	return status == TaskCancelled || status == TaskInterrupted
+}
+
+// Resume 标记任务将从检查点继续
+func (t *Task) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Status = TaskPending
+	t.Error = ""
+	t.BudgetHit = nil
+	t.FinishedAt = time.Time{}
+	t.Resumes++
+}
+
+// GetStatus 当前状态
+func (t *Task) GetStatus() TaskStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Status
+}
+
+// Progress 当前状态、已采集页面数及结束时间
+func (t *Task) Progress() (TaskStatus, int, time.Time) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	c := t.Changes
+	return t.Status, c.New + c.Changed + c.Unchanged + c.NotModified, t.FinishedAt
+}
+
+// SetQueuePosition 更新在等待队列中的位置
+func (t *Task) SetQueuePosition(position int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.QueuePosition = position
+}
+
+// Begin 标记任务开始
+func (t *Task) Begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Status = TaskRunning
+	t.StartedAt = time.Now()
+	t.QueuePosition = 0
+}
+
+// Finish 标记任务结束，err 为 BudgetError 时为预算用尽，为取消/中断时记录相应状态，其他错误为失败
+func (t *Task) Finish(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var budgetErr *BudgetError
+	switch {
+	case errors.As(err, &budgetErr):
+		t.Status = TaskBudgetExhausted
+		t.BudgetHit = budgetErr.Budgets
+	case errors.Is(err, ErrTaskInterrupted):
+		t.Status = TaskInterrupted
+	case errors.Is(err, ErrTaskCancelled) || errors.Is(err, context.Canceled):
+		t.Status = TaskCancelled
+	case err != nil:
+		t.Status = TaskFailed
+		t.Error = err.Error()
+	default:
+		t.Status = TaskFinished
+	}
+	t.FinishedAt = time.Now()
+	t.QueuePosition = 0
+}
+
+// MarshalJSON 加锁后序列化，避免与状态更新并发
+func (t *Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return json.Marshal((*task)(t))
+}
+
+// RecordChange 记录页面变化
+func (t *Task) RecordChange(change string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch change {
+	case ChangeNew:
+		t.Changes.New++
+	case ChangeChanged:
+		t.Changes.Changed++
+	case ChangeUnchanged:
+		t.Changes.Unchanged++
+	case ChangeNotModified:
+		t.Changes.NotModified++
+	}
+}
+
+// RecordDocument 记录写入 DocumentSink 的正文
+func (t *Task) RecordDocument(pageURL string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Documents++
+	t.documents = append(t.documents, pageURL)
+}
+
+// DocumentURLs 写入 DocumentSink 的正文地址
+func (t *Task) DocumentURLs() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]string(nil), t.documents...)
+}
+
+// RecordRobots 记录 robots.txt 禁止采集的地址
+func (t *Task) RecordRobots(u string, crawled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.RobotsDisallowed++
+	if len(t.robots) < maxRobotsRecords {
+		t.robots = append(t.robots, RobotsRecord{URL: u, Crawled: crawled, Time: time.Now()})
+	}
+}
+
+// RobotsRecords robots.txt 禁止采集的地址
+func (t *Task) RobotsRecords() []RobotsRecord {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]RobotsRecord(nil), t.robots...)
+}
@@ -0,0 +1,486 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 站点抽取模板
+// 模板文件为 YAML 或 JSON 数组，可以是单个文件或目录（加载目录下所有 .yaml/.yml/.json）：
+//
+//	- name: tsinghua-news
+//	  host: "*.tsinghua.edu.cn"
+//	  url_pattern: "/info/\\d+/\\d+\\.htm$"
+//	  title: "h1.title"
+//	  time: "//div[@class='meta']/span[1]"
+//	  content: "div.v_news_content"
+//	  remove: [".share", ".print"]
+//	- name: tsinghua-list
+//	  host: "*.tsinghua.edu.cn"
+//	  url_pattern: "\\.htm$"
+//	  list:
+//	    item: "ul.news-list li"
+//	    title: "a"
+//	    date: "span.date"
+//	  next_page: "a.Next"
+//	- name: govdir
+//	  host: "www.govdir.cn"
+//	  directory:
+//	    item: "div.site-list li"
+//	    name: "a"
+//	    category: "./ancestor::div[@class='group']/h3"
+//	  next_page: "a.next"
+//
+// 选择器以 "/"、"(" 或 "xpath:" 开头时按 XPath 处理，其余按 CSS 选择器处理
+
+// Template 站点抽取模板
+type Template struct {
+	Name string `yaml:"name" json:"name"`
+	// 域名，支持 *.example.com 匹配所有子域名
+	Host string `yaml:"host" json:"host"`
+	// URL 正则，为空则匹配该域名下所有页面
+	URLPattern string `yaml:"url_pattern,omitempty" json:"url_pattern,omitempty"`
+
+	Title   string `yaml:"title,omitempty" json:"title,omitempty"`
+	Author  string `yaml:"author,omitempty" json:"author,omitempty"`
+	Time    string `yaml:"time,omitempty" json:"time,omitempty"`
+	Content string `yaml:"content,omitempty" json:"content,omitempty"`
+	// 抽取正文前需要移除的元素
+	Remove []string `yaml:"remove,omitempty" json:"remove,omitempty"`
+
+	List     *ListRule `yaml:"list,omitempty" json:"list,omitempty"`
+	NextPage string    `yaml:"next_page,omitempty" json:"next_page,omitempty"`
+	// 站点目录页规则（政府网站导航、医院/高校名录）
+	Directory *DirectoryRule `yaml:"directory,omitempty" json:"directory,omitempty"`
+
+	urlRe *regexp.Regexp
+}
+
+// ListRule 列表页规则，title/link/date/summary 相对于列表项
+type ListRule struct {
+	Item    string `yaml:"item" json:"item"`
+	Title   string `yaml:"title,omitempty" json:"title,omitempty"`
+	Link    string `yaml:"link" json:"link,omitempty"`
+	Date    string `yaml:"date" json:"date,omitempty"`
+	Summary string `yaml:"summary" json:"summary,omitempty"`
+}
+
+// DirectoryRule 目录页规则，name/link/province/category 相对于目录项
+type DirectoryRule struct {
+	Item     string `yaml:"item" json:"item"`
+	Name     string `yaml:"name,omitempty" json:"name,omitempty"`
+	Link     string `yaml:"link,omitempty" json:"link,omitempty"`
+	Province string `yaml:"province,omitempty" json:"province,omitempty"`
+	Category string `yaml:"category,omitempty" json:"category,omitempty"`
+}
+
+// ListItem 列表页中的一条记录
+type ListItem struct {
+	Title   string    `json:"title"`
+	URL     string    `json:"url"`
+	Date    time.Time `json:"date"`
+	Summary string    `json:"summary,omitempty"`
+}
+
+// ParseTemplates 解析 YAML/JSON 格式的模板列表
+func ParseTemplates(data []byte) ([]*Template, error) {
+	var templates []*Template
+	if err := yaml.Unmarshal(data, &templates); err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if err := t.Compile(); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// Compile 校验模板中的正则和选择器
+func (t *Template) Compile() error {
+	if t.Host == "" {
+		return fmt.Errorf("模板 %s 缺少 host", t.Name)
+	}
+	if t.Name == "" {
+		t.Name = t.Host + t.URLPattern
+	}
+	if t.URLPattern != "" {
+		re, err := regexp.Compile(t.URLPattern)
+		if err != nil {
+			return fmt.Errorf("模板 %s url_pattern 无效: %w", t.Name, err)
+		}
+		t.urlRe = re
+	}
+
+	exprs := append([]string{t.Title, t.Author, t.Time, t.Content, t.NextPage}, t.Remove...)
+	if t.List != nil {
+		exprs = append(exprs, t.List.Item, t.List.Title, t.List.Link, t.List.Date, t.List.Summary)
+	}
+	if t.Directory != nil {
+		exprs = append(exprs, t.Directory.Item, t.Directory.Name, t.Directory.Link, t.Directory.Province, t.Directory.Category)
+	}
+	for _, expr := range exprs {
+		if err := validateSelector(expr); err != nil {
+			return fmt.Errorf("模板 %s 选择器 %q 无效: %w", t.Name, expr, err)
+		}
+	}
+	return nil
+}
+
+// Match 判断模板是否适用于该页面
+func (t *Template) Match(u *url.URL) bool {
+	if !matchHost(t.Host, u.Hostname()) {
+		return false
+	}
+	return t.urlRe == nil || t.urlRe.MatchString(u.String())
+}
+
+func matchHost(pattern string, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(host)
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return strings.HasSuffix(host, suffix) || host == pattern[2:]
+	}
+	return host == pattern
+}
+
+// specificity 匹配优先级：精确域名优先于通配，有 URL 正则的优先于没有的
+func (t *Template) specificity() int {
+	score := 0
+	if !strings.HasPrefix(t.Host, "*.") {
+		score += 2
+	}
+	if t.urlRe != nil {
+		score++
+	}
+	return score
+}
+
+// TemplateStore 模板集合，支持热加载
+type TemplateStore struct {
+	path   string
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	templates []*Template
+	// 上次加载时的文件数和最新修改时间，用于判断是否需要重新加载
+	fileCount int
+	modTime   time.Time
+}
+
+// NewTemplateStore path 为模板文件或目录，为空或不存在时模板集合为空
+func NewTemplateStore(path string, logger *zap.Logger) (*TemplateStore, error) {
+	store := &TemplateStore{
+		path:   path,
+		logger: logger.Named("TemplateStore"),
+	}
+	if err := store.Load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Load 重新加载模板，失败时保留原有模板
+func (s *TemplateStore) Load() error {
+	files, modTime, err := s.files()
+	if err != nil {
+		return err
+	}
+
+	var templates []*Template
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		parsed, err := ParseTemplates(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		templates = append(templates, parsed...)
+	}
+
+	s.Set(templates)
+	s.mu.Lock()
+	s.fileCount = len(files)
+	s.modTime = modTime
+	s.mu.Unlock()
+
+	s.logger.Info(fmt.Sprintf("加载模板 %d 个: %s", len(templates), s.path))
+	return nil
+}
+
+// Set 替换全部模板
+func (s *TemplateStore) Set(templates []*Template) {
+	sorted := append([]*Template(nil), templates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].specificity() > sorted[j].specificity()
+	})
+
+	s.mu.Lock()
+	s.templates = sorted
+	s.mu.Unlock()
+}
+
+// files 模板文件列表及最新修改时间
+func (s *TemplateStore) files() ([]string, time.Time, error) {
+	var latest time.Time
+	if s.path == "" {
+		return nil, latest, nil
+	}
+
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return nil, latest, nil
+	}
+	if err != nil {
+		return nil, latest, err
+	}
+	if !info.IsDir() {
+		return []string{s.path}, info.ModTime(), nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(s.path, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		files = append(files, path)
+		return nil
+	})
+	sort.Strings(files)
+	return files, latest, err
+}
+
+// Watch 定期检查模板文件，有修改时重新加载
+func (s *TemplateStore) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			files, modTime, err := s.files()
+			if err != nil {
+				s.logger.Error("检查模板文件失败", zap.Error(err))
+				continue
+			}
+			s.mu.RLock()
+			changed := len(files) != s.fileCount || !modTime.Equal(s.modTime)
+			s.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := s.Load(); err != nil {
+				s.logger.Error("重新加载模板失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Templates 当前全部模板
+func (s *TemplateStore) Templates() []*Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Template(nil), s.templates...)
+}
+
+// Match 查找适用于页面的模板
+func (s *TemplateStore) Match(pageURL *url.URL) *Template {
+	if s == nil || pageURL == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, t := range s.templates {
+		if t.Match(pageURL) {
+			return t
+		}
+	}
+	return nil
+}
+
+// applyTemplate 使用模板覆盖启发式结果，返回模板指定的正文容器（未命中时为空）
+func (ce *ContentExtractor) applyTemplate(doc *goquery.Document, t *Template, content *ExtractedContent) *goquery.Selection {
+	content.Template = t.Name
+	root := doc.Selection
+
+	if value := selectValue(root, t.Title); value != "" {
+		content.Title = ce.cleanText(value)
+	}
+	if value := selectValue(root, t.Author); value != "" {
+		content.Author = ce.cleanText(value)
+	}
+	if value := selectValue(root, t.Time); value != "" {
+		if pubTime, err := ce.parseTime(value); err == nil {
+			content.PubTime = pubTime
+		}
+	}
+
+	base := documentBaseURL(doc)
+	if t.List != nil {
+		content.ListItems = ce.templateListItems(root, t.List, base)
+	}
+	if t.NextPage != "" {
+		if next := selectAll(root, t.NextPage); next.Length() > 0 {
+			content.NextPage = absoluteURL(base, firstAttr(next.First(), "href", "data-href"))
+		}
+	}
+
+	if t.Content != "" {
+		if matched := selectAll(root, t.Content); matched.Length() > 0 {
+			return matched.First()
+		}
+	}
+	return nil
+}
+
+// removeBoilerplate 移除模板声明的无关元素
+func (t *Template) removeBoilerplate(doc *goquery.Document) {
+	for _, expr := range t.Remove {
+		selectAll(doc.Selection, expr).Remove()
+	}
+}
+
+func (ce *ContentExtractor) templateListItems(root *goquery.Selection, rule *ListRule, base *url.URL) []ListItem {
+	var items []ListItem
+	selectAll(root, rule.Item).Each(func(i int, item *goquery.Selection) {
+		link := item.Find("a[href]").First()
+		if rule.Link != "" {
+			link = selectAll(item, rule.Link).First()
+		}
+
+		title := ce.cleanText(link.AttrOr("title", ""))
+		if rule.Title != "" {
+			title = ce.cleanText(selectValue(item, rule.Title))
+		}
+		if title == "" {
+			title = ce.cleanText(link.Text())
+		}
+
+		entry := ListItem{
+			Title: title,
+			URL:   absoluteURL(base, link.AttrOr("href", "")),
+		}
+		dateText := item.Text()
+		if rule.Date != "" {
+			dateText = selectValue(item, rule.Date)
+		}
+		if date, ok := findDate(dateText); ok {
+			entry.Date = date
+		}
+		if rule.Summary != "" {
+			entry.Summary = ce.cleanText(selectValue(item, rule.Summary))
+		}
+
+		if entry.URL != "" || entry.Title != "" {
+			items = append(items, entry)
+		}
+	})
+	return items
+}
+
+// xpathExpr 判断表达式是否为 XPath，并去掉 xpath: 前缀
+func xpathExpr(expr string) (string, bool) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "xpath:") {
+		return strings.TrimSpace(strings.TrimPrefix(expr, "xpath:")), true
+	}
+	return expr, strings.HasPrefix(expr, "/") || strings.HasPrefix(expr, "(") || strings.HasPrefix(expr, "./")
+}
+
+func validateSelector(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return nil
+	}
+	if xp, ok := xpathExpr(expr); ok {
+		_, err := htmlquery.QueryAll(&html.Node{Type: html.DocumentNode}, xp)
+		return err
+	}
+	_, err := cascadia.Compile(expr)
+	return err
+}
+
+// selectAll 在 root 下执行 CSS 或 XPath 选择器（XPath 只保留元素节点，可用 ancestor、preceding-sibling 等轴选到 root 之外的节点）
+func selectAll(root *goquery.Selection, expr string) *goquery.Selection {
+	if strings.TrimSpace(expr) == "" || root.Length() == 0 {
+		return root.Slice(0, 0)
+	}
+	xp, ok := xpathExpr(expr)
+	if !ok {
+		return root.Find(expr)
+	}
+
+	var nodes []*html.Node
+	for _, n := range root.Nodes {
+		found, err := htmlquery.QueryAll(n, xp)
+		if err != nil {
+			continue
+		}
+		for _, f := range found {
+			if f.Type == html.ElementNode && f.Parent != nil {
+				nodes = append(nodes, f)
+			}
+		}
+	}
+	// 不能用 FindNodes，它只保留 root 的后代；Slice 与 root 共用底层数组，清空后再添加
+	selection := root.Slice(0, 0)
+	selection.Nodes = nil
+	return selection.AddNodes(nodes...)
+}
+
+// selectValue 选择器命中的第一个值：XPath 属性节点取属性值，meta 取 content，其余取文本
+func selectValue(root *goquery.Selection, expr string) string {
+	if strings.TrimSpace(expr) == "" || root.Length() == 0 {
+		return ""
+	}
+	xp, ok := xpathExpr(expr)
+	if !ok {
+		element := root.Find(expr).First()
+		if content, exists := element.Attr("content"); exists && strings.TrimSpace(content) != "" {
+			return strings.TrimSpace(content)
+		}
+		return strings.TrimSpace(element.Text())
+	}
+
+	for _, n := range root.Nodes {
+		found, err := htmlquery.QueryAll(n, xp)
+		if err != nil || len(found) == 0 {
+			continue
+		}
+		if content := htmlquery.SelectAttr(found[0], "content"); found[0].Data == "meta" && content != "" {
+			return strings.TrimSpace(content)
+		}
+		return strings.TrimSpace(htmlquery.InnerText(found[0]))
+	}
+	return ""
+}
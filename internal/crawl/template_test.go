@@ -0,0 +1,103 @@
+package crawl
+
+import (
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const templateYAML = `
+- name: example-list
+  host: "*.example.edu.cn"
+  url_pattern: "/list\\.htm$"
+  list:
+    item: "ul.news li"
+    date: "span"
+  next_page: "//a[text()='下一页']"
+- name: example-detail
+  host: "news.example.edu.cn"
+  url_pattern: "/info/\\d+/\\d+\\.htm$"
+  title: "//div[@class='head']/h3"
+  time: ".meta"
+  content: "#vsb_content"
+  remove: [".share"]
+`
+
+func TestTemplateStore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "example.yaml"), []byte(templateYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewTemplateStore(dir, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Templates()) != 2 {
+		t.Fatalf("templates = %d", len(store.Templates()))
+	}
+
+	extractor := NewContentExtractor()
+	extractor.Templates = store
+
+	detail := `<html><head><title>新闻网</title></head><body>
+	<div class="head"><h3>学校召开工作会议</h3></div>
+	<div class="meta">发布时间：2024年03月05日 09:30 来源：党委办公室</div>
+	<div id="vsb_content"><p>会议强调，要统筹推进各项工作。</p><div class="share">分享到微信</div></div>
+	</body></html>`
+	content, err := extractor.ExtractFromHTMLWithURL(detail, "https://news.example.edu.cn/info/1002/3456.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Template != "example-detail" || content.Title != "学校召开工作会议" {
+		t.Fatalf("template = %s, title = %s", content.Template, content.Title)
+	}
+	if content.PubTime.Format("2006-01-02 15:04") != "2024-03-05 09:30" {
+		t.Errorf("pub time = %s", content.PubTime)
+	}
+	if content.Content != "会议强调，要统筹推进各项工作。" || content.NodePath != "div#vsb_content" {
+		t.Errorf("content = %q, node path = %s", content.Content, content.NodePath)
+	}
+
+	list := `<html><body><ul class="news">
+		<li><a href="/info/1002/1.htm" title="通知一">通知一...</a><span>2024-03-01</span></li>
+		<li><a href="../info/1002/2.htm">通知二</a><span>[2024/02/28]</span></li>
+	</ul><a href="list2.htm">下一页</a></body></html>`
+	content, err = extractor.ExtractFromHTMLWithURL(list, "https://www.example.edu.cn/xwzx/list.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Template != "example-list" || len(content.ListItems) != 2 {
+		t.Fatalf("template = %s, items = %+v", content.Template, content.ListItems)
+	}
+	first, second := content.ListItems[0], content.ListItems[1]
+	if first.Title != "通知一" || first.URL != "https://www.example.edu.cn/info/1002/1.htm" || first.Date.Day() != 1 {
+		t.Errorf("first item = %+v", first)
+	}
+	if second.URL != "https://www.example.edu.cn/info/1002/2.htm" || second.Date.Day() != 28 {
+		t.Errorf("second item = %+v", second)
+	}
+	if content.NextPage != "https://www.example.edu.cn/xwzx/list2.htm" {
+		t.Errorf("next page = %s", content.NextPage)
+	}
+}
+
+func TestSelectAllXPathAxes(t *testing.T) {
+	doc, err := ParseDocument(`<html><body>
+	<div class="group"><h3>部委</h3><ul><li id="a"><a href="/a">甲</a></li><li id="b"><a href="/b">乙</a></li></ul></div>
+	</body></html>`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := doc.Find("li#b")
+	if got := selectAll(item, "./ancestor::div[@class='group']/h3").Text(); got != "部委" {
+		t.Errorf("ancestor = %q", got)
+	}
+	if got := selectAll(item, "./preceding-sibling::li").AttrOr("id", ""); got != "a" {
+		t.Errorf("preceding sibling = %q", got)
+	}
+	if got := selectAll(item, ".//a").Text(); got != "乙" {
+		t.Errorf("descendant = %q", got)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 func normalizeURL(raw string) string {
@@ -237,3 +238,31 @@ func analyzeDOM(doc *goquery.Document) bool {
 
 	return score >= 4
 }
+
+// tokenize 中文按字切分，其他按单词切分并转为小写；抽取评测和 SimHash 共用
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
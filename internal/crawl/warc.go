@@ -0,0 +1,96 @@
+package crawl
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadWARC 读取 WARC（支持 .warc.gz）中的 HTML 响应记录
+func ReadWARC(path string) ([]Page, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var pages []Page
+	reader := bufio.NewReader(r)
+	for {
+		header, block, err := readWARCRecord(reader)
+		if err == io.EOF {
+			return pages, nil
+		}
+		if err != nil {
+			return pages, err
+		}
+
+		if header.Get("WARC-Type") != "response" {
+			continue
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(string(block))), nil)
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK ||
+			!strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html") {
+			continue
+		}
+
+		pages = append(pages, Page{
+			URL:  strings.Trim(header.Get("WARC-Target-URI"), "<>"),
+			HTML: string(body),
+		})
+	}
+}
+
+// readWARCRecord 读取一条记录：版本行、头部、空行、Content-Length 字节的内容、两个换行
+func readWARCRecord(r *bufio.Reader) (textproto.MIMEHeader, []byte, error) {
+	var version string
+	for version == "" {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return nil, nil, io.EOF
+			}
+			return nil, nil, err
+		}
+		version = strings.TrimSpace(line)
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, nil, fmt.Errorf("无效的 WARC 记录: %q", version)
+	}
+
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的 Content-Length: %w", err)
+	}
+
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return nil, nil, err
+	}
+	return header, block, nil
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+// CrawlMetrics 以 Prometheus 指标实现 crawl.Metrics
+type CrawlMetrics struct {
+	requests       *prometheus.CounterVec
+	latency        prometheus.Histogram
+	size           prometheus.Histogram
+	bytes          *prometheus.CounterVec
+	retries        *prometheus.CounterVec
+	extraction     prometheus.Histogram
+	extractErrors  prometheus.Counter
+	sinkWriteError *prometheus.CounterVec
+}
+
+func NewCrawlMetrics(registerer prometheus.Registerer) (*CrawlMetrics, error) {
+	m := &CrawlMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "crawl",
+			Name:      "requests_total",
+			Help:      "Crawl requests by host and status code, status is \"error\" for network errors.",
+		}, []string{"host", "status"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "crawl",
+			Name:      "response_duration_seconds",
+			Help:      "Time from sending a crawl request to its response or error.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		size: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "crawl",
+			Name:      "response_size_bytes",
+			Help:      "Body size of crawl responses.",
+			// 1 KiB - 16 MiB
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 8),
+		}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "crawl",
+			Name:      "response_bytes_total",
+			Help:      "Downloaded bytes by host.",
+		}, []string{"host"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "crawl",
+			Name:      "retries_total",
+			Help:      "Crawl requests sent again by host.",
+		}, []string{"host"}),
+		extraction: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "extract",
+			Name:      "duration_seconds",
+			Help:      "Time spent extracting the content of a page.",
+			// 1ms - 2s
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+		}),
+		extractErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "extract",
+			Name:      "failures_total",
+			Help:      "Pages whose content could not be extracted.",
+		}),
+		sinkWriteError: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sink",
+			Name:      "write_errors_total",
+			Help:      "Failed writes by sink: document, attachment, checkpoint or state.",
+		}, []string{"sink"}),
+	}
+	for _, c := range []prometheus.Collector{
+		m.requests, m.latency, m.size, m.bytes, m.retries, m.extraction, m.extractErrors, m.sinkWriteError,
+	} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *CrawlMetrics) Request(host string, status int, latency time.Duration, size int) {
+	label := "error"
+	if status > 0 {
+		label = strconv.Itoa(status)
+	}
+	m.requests.WithLabelValues(host, label).Inc()
+	if latency > 0 {
+		m.latency.Observe(latency.Seconds())
+	}
+	if size > 0 {
+		m.size.Observe(float64(size))
+		m.bytes.WithLabelValues(host).Add(float64(size))
+	}
+}
+
+func (m *CrawlMetrics) Retry(host string) {
+	m.retries.WithLabelValues(host).Inc()
+}
+
+func (m *CrawlMetrics) Extraction(duration time.Duration, err error) {
+	m.extraction.Observe(duration.Seconds())
+	if err != nil {
+		m.extractErrors.Inc()
+	}
+}
+
+func (m *CrawlMetrics) SinkError(sink string) {
+	m.sinkWriteError.WithLabelValues(sink).Inc()
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.uber.org/fx"
+	"seed-detect/internal/crawl"
+)
+
+// Prometheus 监控指标
+// Module 提供进程内唯一的 Registry，采集、任务队列的指标在此注册，/metrics 从 Gatherer 输出。
+// 其他组件注入 prometheus.Registerer 注册自己的指标即可，例如
+//
+//	fx.Invoke(func(registerer prometheus.Registerer) error {
+//		return registerer.Register(collector)
+//	})
+
+// namespace 指标名前缀
+const namespace = "seed"
+
+var Module = fx.Module("metrics",
+	fx.Provide(
+		NewRegistry,
+		func(registry *prometheus.Registry) prometheus.Registerer {
+			return registry
+		},
+		func(registry *prometheus.Registry) prometheus.Gatherer {
+			return registry
+		},
+		NewCrawlMetrics,
+		func(m *CrawlMetrics) crawl.Metrics {
+			return m
+		},
+	),
+	fx.Invoke(RegisterTaskMetrics),
+)
+
+// NewRegistry 包含 Go 运行时及进程指标的 Registry
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
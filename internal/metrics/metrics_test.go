@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"errors"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"seed-detect/internal/crawl"
+	"seed-detect/internal/task"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := NewRegistry()
+	m, err := NewCrawlMetrics(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ crawl.Metrics = m
+	m.Request("www.example.gov.cn", 200, 120*time.Millisecond, 4096)
+	m.Request("www.example.gov.cn", 200, 80*time.Millisecond, 1024)
+	m.Request("www.example.gov.cn", 0, time.Second, 0)
+	m.Retry("www.example.gov.cn")
+	m.Extraction(5*time.Millisecond, nil)
+	m.Extraction(time.Millisecond, errors.New("empty document"))
+	m.SinkError(crawl.SinkDocument)
+
+	manager := task.NewManager(nil, nil, nil, nil, nil, task.Config{MaxRunning: 2, QueueSize: 10}, zap.NewNop())
+	if err := RegisterTaskMetrics(registry, manager); err != nil {
+		t.Fatal(err)
+	}
+	// 重复注册返回错误
+	if _, err := NewCrawlMetrics(registry); err == nil {
+		t.Error("registered crawl metrics twice")
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			values[family.GetName()+labels(metric)] = value(metric)
+		}
+	}
+	for name, want := range map[string]float64{
+		`seed_crawl_requests_total{host="www.example.gov.cn",status="200"}`:   2,
+		`seed_crawl_requests_total{host="www.example.gov.cn",status="error"}`: 1,
+		`seed_crawl_response_bytes_total{host="www.example.gov.cn"}`:          5120,
+		`seed_crawl_retries_total{host="www.example.gov.cn"}`:                 1,
+		`seed_crawl_response_duration_seconds`:                                3,
+		`seed_extract_duration_seconds`:                                       2,
+		`seed_extract_failures_total`:                                         1,
+		`seed_sink_write_errors_total{sink="document"}`:                       1,
+		`seed_tasks_max_running`:                                              2,
+		`seed_tasks_queue_capacity`:                                           10,
+		`seed_tasks_queue_depth`:                                              0,
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func labels(metric *dto.Metric) string {
+	if len(metric.GetLabel()) == 0 {
+		return ""
+	}
+	s := "{"
+	for i, label := range metric.GetLabel() {
+		if i > 0 {
+			s += ","
+		}
+		s += label.GetName() + `="` + label.GetValue() + `"`
+	}
+	return s + "}"
+}
+
+// value 计数器及仪表取值，直方图取样本数
+func value(metric *dto.Metric) float64 {
+	switch {
+	case metric.Counter != nil:
+		return metric.Counter.GetValue()
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue()
+	case metric.Histogram != nil:
+		return float64(metric.Histogram.GetSampleCount())
+	}
+	return 0
+}
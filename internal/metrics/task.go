@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"seed-detect/internal/task"
+)
+
+// RegisterTaskMetrics 任务队列的指标，抓取时读取当前状态
+func RegisterTaskMetrics(registerer prometheus.Registerer, manager *task.Manager) error {
+	gauges := []struct {
+		name, help string
+		value      func(task.QueueStats) int
+	}{
+		{"running", "Crawl tasks running now.", func(s task.QueueStats) int { return s.Running }},
+		{"max_running", "Max crawl tasks running at the same time.", func(s task.QueueStats) int { return s.MaxRunning }},
+		{"queue_depth", "Crawl tasks waiting in the queue.", func(s task.QueueStats) int { return s.Queued }},
+		{"queue_capacity", "Max crawl tasks waiting in the queue.", func(s task.QueueStats) int { return s.Capacity }},
+	}
+	for _, g := range gauges {
+		value := g.value
+		err := registerer.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "tasks",
+			Name:      g.name,
+			Help:      g.help,
+		}, func() float64 {
+			return float64(value(manager.QueueStats()))
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
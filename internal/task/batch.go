@@ -0,0 +1,167 @@
+package task
+
+import (
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"seed-detect/internal/crawl"
+	"time"
+)
+
+// 批量采集
+// 一次提交大量种子（如一个省的全部高校）时创建批量作业，每个种子一个子任务。
+// 子任务按提交顺序进入等待队列，所有作业共用 Config.BatchConcurrency 个名额，避免一个作业占满队列，
+// 未轮到的子任务保持 pending
+
+// JobStatus 批量作业状态
+type JobStatus string
+
+const (
+	JobRunning  JobStatus = "running"
+	JobFinished JobStatus = "finished"
+)
+
+// Seed 批量作业中的一个种子
+type Seed struct {
+	URL     string
+	Options crawl.Options
+}
+
+// Job 批量采集作业
+type Job struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	TaskIDs   []string  `json:"task_ids"`
+	// 汇总进度，查询时填充
+	Progress JobProgress `json:"progress"`
+}
+
+// JobProgress 子任务的汇总进度
+type JobProgress struct {
+	Status JobStatus `json:"status"`
+	Total  int       `json:"total"`
+	// 已结束（含失败、取消）的子任务数
+	Done int `json:"done"`
+	// 各状态的子任务数
+	Statuses map[crawl.TaskStatus]int `json:"statuses"`
+	// 已采集的页面数
+	Pages      int       `json:"pages"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// SubmitBatch 创建批量作业，子任务在后台按名额依次运行，等待队列已满时返回 ErrQueueFull
+func (m *Manager) SubmitBatch(name string, seeds []Seed) (*Job, error) {
+	job := &Job{
+		ID:        uuid.NewString(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	tasks := make([]*crawl.Task, 0, len(seeds))
+	m.mu.Lock()
+	if len(m.queue) >= m.config.QueueSize {
+		m.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	for _, seed := range seeds {
+		t := crawl.NewTask(seed.URL, seed.Options)
+		m.tasks[t.ID] = t
+		m.order = append(m.order, t.ID)
+		job.TaskIDs = append(job.TaskIDs, t.ID)
+		tasks = append(tasks, t)
+	}
+	m.jobs[job.ID] = job
+	m.jobOrder = append(m.jobOrder, job.ID)
+	m.mu.Unlock()
+
+	m.persist(tasks...)
+	if m.store != nil {
+		if err := m.store.SaveJob(job); err != nil {
+			m.logger.Error("批量作业保存失败", zap.String("job", job.ID), zap.Error(err))
+		}
+	}
+
+	m.logger.Info("批量作业已创建", zap.String("job", job.ID), zap.String("name", name), zap.Int("tasks", len(tasks)))
+	go m.runJob(job, tasks)
+	return m.jobView(job), nil
+}
+
+// runJob 依次为子任务占用名额并加入等待队列，任务结束后释放；
+// 等待名额期间被取消（或取消后又继续、已自行入队）的子任务跳过
+func (m *Manager) runJob(job *Job, tasks []*crawl.Task) {
+	for _, t := range tasks {
+		select {
+		case m.batchSlots <- struct{}{}:
+		case <-m.ctx.Done():
+			return
+		}
+		m.mu.Lock()
+		if t.GetStatus() != crawl.TaskPending || m.tracked(t.ID) {
+			m.mu.Unlock()
+			<-m.batchSlots
+			continue
+		}
+		done, _ := m.enqueue(t, true)
+		m.mu.Unlock()
+		go func() {
+			<-done
+			<-m.batchSlots
+		}()
+	}
+	m.logger.Info("批量作业的子任务已全部入队", zap.String("job", job.ID))
+}
+
+// GetJob 按 ID 查询批量作业
+func (m *Manager) GetJob(id string) (*Job, bool) {
+	m.mu.RLock()
+	job, ok := m.jobs[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return m.jobView(job), true
+}
+
+// ListJobs 按提交顺序返回所有批量作业
+func (m *Manager) ListJobs() []*Job {
+	m.mu.RLock()
+	jobs := make([]*Job, 0, len(m.jobOrder))
+	for _, id := range m.jobOrder {
+		jobs = append(jobs, m.jobs[id])
+	}
+	m.mu.RUnlock()
+	for i, job := range jobs {
+		jobs[i] = m.jobView(job)
+	}
+	return jobs
+}
+
+// jobView 作业副本，附带子任务的汇总进度
+func (m *Manager) jobView(job *Job) *Job {
+	v := *job
+	v.Progress = JobProgress{
+		Status:   JobFinished,
+		Total:    len(job.TaskIDs),
+		Statuses: map[crawl.TaskStatus]int{},
+	}
+	for _, id := range job.TaskIDs {
+		t, ok := m.Get(id)
+		if !ok {
+			continue
+		}
+		status, pages, finishedAt := t.Progress()
+		v.Progress.Statuses[status]++
+		v.Progress.Pages += pages
+		if status == crawl.TaskPending || status == crawl.TaskRunning {
+			v.Progress.Status = JobRunning
+			continue
+		}
+		v.Progress.Done++
+		if finishedAt.After(v.Progress.FinishedAt) {
+			v.Progress.FinishedAt = finishedAt
+		}
+	}
+	if v.Progress.Status == JobRunning {
+		v.Progress.FinishedAt = time.Time{}
+	}
+	return &v
+}
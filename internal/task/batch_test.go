@@ -0,0 +1,127 @@
+package task
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"seed-detect/internal/crawl"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitBatch(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><p>首页</p></body></html>`)
+	}))
+	defer server.Close()
+
+	checkpoints, err := crawl.NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := crawl.NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spider := crawl.NewSpider(nil, nil, crawl.NewRobotsCache("", 0), checkpoints, states,
+		crawl.NewDirDocumentSink(t.TempDir()), nil, zap.NewNop())
+	manager := NewManager(spider, nil, checkpoints, nil, nil, Config{BatchConcurrency: 2}, zap.NewNop())
+
+	var seeds []Seed
+	for i := 0; i < 5; i++ {
+		seeds = append(seeds, Seed{URL: fmt.Sprintf("%s/site%d/", server.URL, i), Options: crawl.Options{MaxDepth: 1}})
+	}
+	job, err := manager.SubmitBatch("高校", seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Progress.Total != 5 || len(job.TaskIDs) != 5 || len(manager.List()) != 5 {
+		t.Fatalf("job = %+v", job)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Progress.Status != JobFinished && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		job, _ = manager.GetJob(job.ID)
+	}
+	if job.Progress.Done != 5 || job.Progress.Statuses[crawl.TaskFinished] != 5 || job.Progress.Pages != 5 || job.Progress.FinishedAt.IsZero() {
+		t.Fatalf("progress = %+v", job.Progress)
+	}
+	if max := maxInFlight.Load(); max > 2 {
+		t.Errorf("max concurrent tasks = %d", max)
+	}
+	if jobs := manager.ListJobs(); len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("jobs = %+v", jobs)
+	}
+}
+
+func TestCancelBatchChildWaitingForSlot(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><p>首页</p></body></html>`)
+	}))
+	defer server.Close()
+
+	checkpoints, err := crawl.NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := crawl.NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spider := crawl.NewSpider(nil, nil, crawl.NewRobotsCache("", 0), checkpoints, states,
+		crawl.NewDirDocumentSink(t.TempDir()), nil, zap.NewNop())
+	manager := NewManager(spider, nil, checkpoints, nil, nil, Config{BatchConcurrency: 1}, zap.NewNop())
+
+	job, err := manager.SubmitBatch("高校", []Seed{
+		{URL: server.URL + "/site0/", Options: crawl.Options{MaxDepth: 1}},
+		{URL: server.URL + "/site1/", Options: crawl.Options{MaxDepth: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一个子任务占用唯一的名额，第二个在等待名额
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	second := job.TaskIDs[1]
+	if err := manager.Cancel(second); err != nil {
+		t.Fatalf("cancel waiting child: %v", err)
+	}
+	if task, _ := manager.Get(second); task.GetStatus() != crawl.TaskCancelled {
+		t.Fatalf("status after cancel = %s", task.GetStatus())
+	}
+	close(release)
+
+	for job.Progress.Status != JobFinished && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		job, _ = manager.GetJob(job.ID)
+	}
+	if job.Progress.Statuses[crawl.TaskFinished] != 1 || job.Progress.Statuses[crawl.TaskCancelled] != 1 {
+		t.Fatalf("progress = %+v", job.Progress)
+	}
+	// 名额释放后被取消的子任务不再运行
+	time.Sleep(100 * time.Millisecond)
+	if task, _ := manager.Get(second); task.GetStatus() != crawl.TaskCancelled || requests.Load() != 1 {
+		t.Errorf("status = %s, requests = %d", task.GetStatus(), requests.Load())
+	}
+}
@@ -0,0 +1,424 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"seed-detect/internal/crawl"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency 批量作业同时运行的任务数
+const DefaultBatchConcurrency = 4
+
+// Config 任务管理配置，为 0 的项使用默认值
+type Config struct {
+	// 同时运行的任务数上限
+	MaxRunning int
+	// 等待队列的容量
+	QueueSize int
+	// 所有批量作业的子任务同时运行或排队的上限
+	BatchConcurrency int
+	// 结束超过该时长的任务连同检查点、正文一起删除，为 0 时不清理
+	Retention time.Duration
+}
+
+// Manager 采集任务管理：提交、排队、查询、取消及从检查点继续
+type Manager struct {
+	spider      *crawl.Spider
+	templates   *crawl.TemplateStore
+	checkpoints *crawl.CheckpointStore
+	documents   crawl.DocumentSink
+	// 为 nil 时不持久化
+	store  TaskStore
+	logger *zap.Logger
+
+	mu    sync.RWMutex
+	tasks map[string]*crawl.Task
+	// 提交顺序
+	order []string
+	// 运行中任务的取消函数
+	cancels map[string]context.CancelCauseFunc
+	running sync.WaitGroup
+
+	config Config
+	// 等待队列，按优先级从高到低、同一优先级按入队顺序
+	queue []*queued
+	// 运行中的任务数
+	active int
+
+	// 批量作业及子任务的名额
+	jobs       map[string]*Job
+	jobOrder   []string
+	batchSlots chan struct{}
+
+	// Shutdown 后不再启动新任务
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+// DirectoryHarvest 目录采集结果及为成员站点创建的任务
+type DirectoryHarvest struct {
+	Entries []crawl.DirectoryEntry `json:"entries"`
+	Tasks   []*crawl.Task          `json:"tasks"`
+	// 因等待队列已满未创建任务的站点数
+	Skipped int `json:"skipped,omitempty"`
+}
+
+func NewManager(spider *crawl.Spider, templates *crawl.TemplateStore, checkpoints *crawl.CheckpointStore, documents crawl.DocumentSink, store TaskStore, config Config, logger *zap.Logger) *Manager {
+	if config.MaxRunning <= 0 {
+		config.MaxRunning = DefaultMaxRunning
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.BatchConcurrency <= 0 {
+		config.BatchConcurrency = DefaultBatchConcurrency
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Manager{
+		spider:      spider,
+		templates:   templates,
+		checkpoints: checkpoints,
+		documents:   documents,
+		store:       store,
+		logger:      logger.Named("TaskManager"),
+		tasks:       map[string]*crawl.Task{},
+		cancels:     map[string]context.CancelCauseFunc{},
+		config:      config,
+		jobs:        map[string]*Job{},
+		batchSlots:  make(chan struct{}, config.BatchConcurrency),
+		ctx:         ctx,
+		stop:        stop,
+	}
+}
+
+// Submit 创建任务并加入等待队列，队列已满时返回 ErrQueueFull
+func (m *Manager) Submit(target string, opts crawl.Options) (*crawl.Task, error) {
+	t := crawl.NewTask(target, opts)
+
+	m.mu.Lock()
+	if _, err := m.enqueue(t, false); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	m.tasks[t.ID] = t
+	m.order = append(m.order, t.ID)
+	m.mu.Unlock()
+
+	m.persist(t)
+	return t, nil
+}
+
+// persist 保存任务的当前状态
+func (m *Manager) persist(tasks ...*crawl.Task) {
+	if m.store == nil || len(tasks) == 0 {
+		return
+	}
+	records := make([]*crawl.TaskRecord, 0, len(tasks))
+	for _, t := range tasks {
+		records = append(records, t.Record())
+	}
+	if err := m.store.SaveTasks(records...); err != nil {
+		m.logger.Error("任务保存失败", zap.Int("tasks", len(tasks)), zap.Error(err))
+	}
+}
+
+func (m *Manager) run(ctx context.Context, t *crawl.Task) {
+	m.persist(t)
+	defer m.persist(t)
+	err := m.spider.Start(ctx, t)
+	var budgetErr *crawl.BudgetError
+	if errors.As(err, &budgetErr) {
+		m.logger.Info("采集预算已用尽", zap.String("task", t.ID), zap.Strings("budgets", budgetErr.Budgets))
+	} else if errors.Is(err, crawl.ErrTaskCancelled) || errors.Is(err, crawl.ErrTaskInterrupted) {
+		m.logger.Info("采集任务已停止", zap.String("task", t.ID), zap.Error(err))
+	} else if err != nil {
+		m.logger.Error("采集任务失败", zap.String("task", t.ID), zap.Error(err))
+	}
+	t.Finish(err)
+}
+
+// Cancel 取消运行中、排队中或等待批量名额的任务，已采集的进度保存在检查点中
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cancel, ok := m.cancels[id]; ok {
+		cancel(crawl.ErrTaskCancelled)
+		return nil
+	}
+	if item, ok := m.dequeue(id); ok {
+		item.task.Finish(crawl.ErrTaskCancelled)
+		close(item.done)
+		m.persist(item.task)
+		return nil
+	}
+	// 批量作业中尚未轮到的子任务既不在运行也不在队列中，直接结束，runJob 轮到时跳过
+	if t, ok := m.tasks[id]; ok && t.GetStatus() == crawl.TaskPending {
+		t.Finish(crawl.ErrTaskCancelled)
+		m.persist(t)
+		return nil
+	}
+	return fmt.Errorf("任务未在运行或排队: %s", id)
+}
+
+// Resume 将已取消或中断的任务重新加入等待队列，运行时从检查点继续
+func (m *Manager) Resume(id string) (*crawl.Task, error) {
+	return m.resume(id, false)
+}
+
+// resume force 为 true 时不受队列容量限制
+func (m *Manager) resume(id string, force bool) (*crawl.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("任务不存在: %s", id)
+	}
+	if _, running := m.cancels[id]; running || !t.Resumable() {
+		return nil, fmt.Errorf("任务状态为 %s，无法继续", t.GetStatus())
+	}
+	if !force && len(m.queue) >= m.config.QueueSize {
+		return nil, ErrQueueFull
+	}
+	t.Resume()
+	_, _ = m.enqueue(t, true)
+	m.persist(t)
+	return t, nil
+}
+
+// Restore 加载保存的任务、批量作业及检查点：排队中的任务重新入队，
+// resume 为 true 时自动继续因进程退出而中断的任务。批量作业中排队的子任务恢复后不再受 BatchConcurrency 限制
+func (m *Manager) Restore(resume bool) error {
+	var records []*crawl.TaskRecord
+	var jobs []*Job
+	if m.store != nil {
+		var err error
+		if records, err = m.store.LoadTasks(); err != nil {
+			m.logger.Error("部分任务加载失败", zap.Error(err))
+		}
+		if jobs, err = m.store.LoadJobs(); err != nil {
+			m.logger.Error("部分批量作业加载失败", zap.Error(err))
+		}
+	}
+	checkpoints, err := m.checkpoints.List()
+	if err != nil {
+		return err
+	}
+
+	var pending, interrupted []*crawl.Task
+	restore := func(t *crawl.Task) {
+		if _, ok := m.tasks[t.ID]; ok {
+			return
+		}
+		m.tasks[t.ID] = t
+		m.order = append(m.order, t.ID)
+		switch t.Status {
+		case crawl.TaskPending:
+			pending = append(pending, t)
+		case crawl.TaskInterrupted:
+			interrupted = append(interrupted, t)
+		}
+	}
+	m.mu.Lock()
+	for _, record := range records {
+		restore(crawl.LoadTask(record))
+	}
+	// 没有保存记录的任务由检查点重建
+	for _, cp := range checkpoints {
+		restore(crawl.RestoreTask(cp))
+	}
+	for _, job := range jobs {
+		if _, ok := m.jobs[job.ID]; !ok {
+			m.jobs[job.ID] = job
+			m.jobOrder = append(m.jobOrder, job.ID)
+		}
+	}
+	for _, t := range pending {
+		_, _ = m.enqueue(t, true)
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("已加载任务", zap.Int("tasks", len(records)), zap.Int("checkpoints", len(checkpoints)),
+		zap.Int("jobs", len(jobs)), zap.Int("pending", len(pending)), zap.Int("interrupted", len(interrupted)))
+	if !resume {
+		return nil
+	}
+	for _, t := range interrupted {
+		if _, err := m.resume(t.ID, true); err != nil {
+			m.logger.Error("继续中断任务失败", zap.String("task", t.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Cleanup 删除结束时间早于 before 的任务及其检查点、写入的正文，子任务均已删除的批量作业一并删除，返回删除的任务数。
+// 正文已被之后的任务覆盖时保留
+func (m *Manager) Cleanup(before time.Time) int {
+	var expired []*crawl.Task
+	var expiredJobs []string
+	m.mu.Lock()
+	order := make([]string, 0, len(m.order))
+	for _, id := range m.order {
+		t := m.tasks[id]
+		status, _, finishedAt := t.Progress()
+		_, running := m.cancels[id]
+		if !running && status != crawl.TaskPending && status != crawl.TaskRunning && !finishedAt.IsZero() && finishedAt.Before(before) {
+			expired = append(expired, t)
+			delete(m.tasks, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	m.order = order
+	jobOrder := make([]string, 0, len(m.jobOrder))
+	for _, id := range m.jobOrder {
+		alive := false
+		for _, taskID := range m.jobs[id].TaskIDs {
+			if _, ok := m.tasks[taskID]; ok {
+				alive = true
+				break
+			}
+		}
+		if !alive {
+			expiredJobs = append(expiredJobs, id)
+			delete(m.jobs, id)
+			continue
+		}
+		jobOrder = append(jobOrder, id)
+	}
+	m.jobOrder = jobOrder
+	m.mu.Unlock()
+
+	remover, _ := m.documents.(crawl.DocumentRemover)
+	for _, t := range expired {
+		if err := m.checkpoints.Delete(t.ID); err != nil {
+			m.logger.Error("检查点删除失败", zap.String("task", t.ID), zap.Error(err))
+		}
+		if remover != nil {
+			for _, pageURL := range t.DocumentURLs() {
+				if err := remover.Remove(t.ID, pageURL); err != nil {
+					m.logger.Error("正文删除失败", zap.String("task", t.ID), zap.String("url", pageURL), zap.Error(err))
+				}
+			}
+		}
+		if m.store != nil {
+			if err := m.store.DeleteTask(t.ID); err != nil {
+				m.logger.Error("任务删除失败", zap.String("task", t.ID), zap.Error(err))
+			}
+		}
+	}
+	for _, id := range expiredJobs {
+		if m.store != nil {
+			if err := m.store.DeleteJob(id); err != nil {
+				m.logger.Error("批量作业删除失败", zap.String("job", id), zap.Error(err))
+			}
+		}
+	}
+	if len(expired) > 0 || len(expiredJobs) > 0 {
+		m.logger.Info("已清理过期任务", zap.Int("tasks", len(expired)), zap.Int("jobs", len(expiredJobs)))
+	}
+	return len(expired)
+}
+
+// RunCleanup 定期清理结束超过 Config.Retention 的任务，阻塞至 ctx 取消
+func (m *Manager) RunCleanup(ctx context.Context, interval time.Duration) {
+	if m.config.Retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Cleanup(time.Now().Add(-m.config.Retention))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown 中断所有运行中的任务并等待检查点写入完成
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.stop()
+	for _, cancel := range m.cancels {
+		cancel(crawl.ErrTaskInterrupted)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get 按 ID 查询任务
+func (m *Manager) Get(id string) (*crawl.Task, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.tasks[id]
+	return t, ok
+}
+
+// List 按提交顺序返回所有任务
+func (m *Manager) List() []*crawl.Task {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tasks := make([]*crawl.Task, 0, len(m.order))
+	for _, id := range m.order {
+		tasks = append(tasks, m.tasks[id])
+	}
+	return tasks
+}
+
+// HarvestDirectory 收集目录中的成员站点，submit 为 true 时为每个站点创建采集任务
+// 开启校验时只为首页可访问的站点创建任务，并使用跳转后的地址
+func (m *Manager) HarvestDirectory(ctx context.Context, directoryURL string, harvest crawl.HarvestOptions, opts crawl.Options, submit bool) (*DirectoryHarvest, error) {
+	extractor := crawl.NewContentExtractor()
+	extractor.Templates = m.templates
+
+	entries, err := extractor.HarvestDirectory(ctx, directoryURL, harvest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DirectoryHarvest{Entries: entries}
+	if !submit {
+		return result, nil
+	}
+	for _, entry := range entries {
+		if harvest.Validate && !entry.Resolved {
+			continue
+		}
+		target := entry.URL
+		if entry.FinalURL != "" {
+			target = entry.FinalURL
+		}
+		if result.Skipped > 0 {
+			result.Skipped++
+			continue
+		}
+		t, err := m.Submit(target, opts)
+		if err != nil {
+			result.Skipped++
+			continue
+		}
+		result.Tasks = append(result.Tasks, t)
+	}
+	if result.Skipped > 0 {
+		m.logger.Warn("等待队列已满，部分站点未创建任务", zap.String("url", directoryURL), zap.Int("skipped", result.Skipped))
+	}
+	m.logger.Info("目录采集完成", zap.String("url", directoryURL),
+		zap.Int("entries", len(entries)), zap.Int("tasks", len(result.Tasks)))
+	return result, nil
+}
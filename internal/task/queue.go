@@ -0,0 +1,130 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"seed-detect/internal/crawl"
+	"slices"
+	"sort"
+)
+
+// 任务队列
+// 提交的任务先进入等待队列，最多同时运行 Config.MaxRunning 个，按优先级从高到低、同一优先级按提交顺序启动。
+// 队列中的任务数达到 Config.QueueSize 时拒绝新的提交；批量作业的子任务和启动时恢复的任务不受该限制
+
+const (
+	DefaultMaxRunning = 4
+	DefaultQueueSize  = 1000
+)
+
+// ErrQueueFull 等待队列已满
+var ErrQueueFull = errors.New("等待队列已满，请稍后再提交")
+
+// queued 等待中的任务
+type queued struct {
+	task  *crawl.Task
+	level int
+	// 任务结束或在排队时被取消后关闭
+	done chan struct{}
+}
+
+// QueueStats 队列状态
+type QueueStats struct {
+	Running    int `json:"running"`
+	MaxRunning int `json:"max_running"`
+	Queued     int `json:"queued"`
+	Capacity   int `json:"capacity"`
+	// 等待中的任务，按启动顺序
+	Tasks []string `json:"tasks"`
+}
+
+// enqueue 加入等待队列并尝试启动，force 为 false 且队列已满时返回 ErrQueueFull（调用方持有锁）
+func (m *Manager) enqueue(t *crawl.Task, force bool) (<-chan struct{}, error) {
+	if !force && len(m.queue) >= m.config.QueueSize {
+		return nil, ErrQueueFull
+	}
+	item := &queued{
+		task:  t,
+		level: t.Options.Priority.Level(),
+		done:  make(chan struct{}),
+	}
+	// 排在同一优先级的最后
+	i := sort.Search(len(m.queue), func(i int) bool {
+		return m.queue[i].level < item.level
+	})
+	m.queue = slices.Insert(m.queue, i, item)
+	m.dispatch()
+	return item.done, nil
+}
+
+// dispatch 有空闲名额时按顺序启动等待中的任务，并更新其余任务的排队位置（调用方持有锁）
+func (m *Manager) dispatch() {
+	for m.ctx.Err() == nil && m.active < m.config.MaxRunning && len(m.queue) > 0 {
+		item := m.queue[0]
+		m.queue = m.queue[1:]
+		m.launch(item)
+	}
+	for i, item := range m.queue {
+		item.task.SetQueuePosition(i + 1)
+	}
+}
+
+// launch 在后台运行任务，结束后释放名额（调用方持有锁）
+func (m *Manager) launch(item *queued) {
+	t := item.task
+	ctx, cancel := context.WithCancelCause(context.Background())
+	m.cancels[t.ID] = cancel
+	m.active++
+	m.running.Add(1)
+	t.Begin()
+
+	go func() {
+		defer m.running.Done()
+		defer close(item.done)
+		m.run(ctx, t)
+		cancel(nil)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.cancels, t.ID)
+		m.active--
+		m.dispatch()
+	}()
+}
+
+// dequeue 从等待队列中移除任务（调用方持有锁）
+func (m *Manager) dequeue(id string) (*queued, bool) {
+	for i, item := range m.queue {
+		if item.task.ID == id {
+			m.queue = slices.Delete(m.queue, i, i+1)
+			m.dispatch()
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// tracked 任务正在运行或在等待队列中（调用方持有锁）
+func (m *Manager) tracked(id string) bool {
+	if _, ok := m.cancels[id]; ok {
+		return true
+	}
+	return slices.ContainsFunc(m.queue, func(item *queued) bool { return item.task.ID == id })
+}
+
+// QueueStats 当前运行及等待中的任务
+func (m *Manager) QueueStats() QueueStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stats := QueueStats{
+		Running:    m.active,
+		MaxRunning: m.config.MaxRunning,
+		Queued:     len(m.queue),
+		Capacity:   m.config.QueueSize,
+		Tasks:      make([]string, 0, len(m.queue)),
+	}
+	for _, item := range m.queue {
+		stats.Tasks = append(stats.Tasks, item.task.ID)
+	}
+	return stats
+}
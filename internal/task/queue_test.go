@@ -0,0 +1,79 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"seed-detect/internal/crawl"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var started []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		started = append(started, r.URL.Path)
+		mu.Unlock()
+		<-release
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><p>首页</p></body></html>`)
+	}))
+	defer server.Close()
+
+	checkpoints, err := crawl.NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := crawl.NewPageStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spider := crawl.NewSpider(nil, nil, crawl.NewRobotsCache("", 0), checkpoints, states,
+		crawl.NewDirDocumentSink(t.TempDir()), nil, zap.NewNop())
+	manager := NewManager(spider, nil, checkpoints, nil, nil, Config{MaxRunning: 1, QueueSize: 2}, zap.NewNop())
+	submit := func(path string, priority crawl.Priority) (*crawl.Task, error) {
+		return manager.Submit(server.URL+path, crawl.Options{MaxDepth: 1, Priority: priority})
+	}
+
+	running, _ := submit("/running", crawl.PriorityLow)
+	low, _ := submit("/low", crawl.PriorityLow)
+	normal, _ := submit("/normal", crawl.PriorityNormal)
+	// 队列已满，先取消排队中的任务再提交
+	if _, err := submit("/rejected", crawl.PriorityHigh); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("submit to full queue: %v", err)
+	}
+	if err := manager.Cancel(low.ID); err != nil || low.GetStatus() != crawl.TaskCancelled {
+		t.Fatalf("cancel queued: %v, %s", err, low.GetStatus())
+	}
+	high, err := submit("/high", crawl.PriorityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := manager.QueueStats()
+	if running.GetStatus() != crawl.TaskRunning || stats.Running != 1 || stats.Queued != 2 ||
+		stats.Tasks[0] != high.ID || high.QueuePosition != 1 || normal.QueuePosition != 2 {
+		t.Fatalf("queue = %+v, positions = %d, %d", stats, high.QueuePosition, normal.QueuePosition)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for normal.GetStatus() != crawl.TaskFinished && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(started) != "[/running /high /normal]" || normal.QueuePosition != 0 {
+		t.Errorf("started = %v", started)
+	}
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
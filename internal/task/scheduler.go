@@ -0,0 +1,284 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"seed-detect/internal/crawl"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 定时采集
+// 种子按 cron 表达式（如 "0 * * * *"、"@weekly"）或固定间隔（如 "1h"）周期性创建采集任务，
+// 任务经 Manager.Submit 创建，与接口提交的任务一致。同一计划上次的任务尚未结束时跳过本次运行。
+// 计划保存在本地 json 文件中，重启后继续按计划运行，错过的运行在启动后补一次
+
+// Schedule 定时采集计划，Cron 和 Interval 二选一
+type Schedule struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	URL      string        `json:"url"`
+	Options  crawl.Options `json:"options"`
+	Cron     string        `json:"cron,omitempty"`
+	Interval string        `json:"interval,omitempty"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	NextRun    time.Time `json:"next_run"`
+	LastRun    time.Time `json:"last_run"`
+	LastTaskID string    `json:"last_task_id,omitempty"`
+	// 上次任务的状态，查询时填充
+	LastStatus crawl.TaskStatus `json:"last_status,omitempty"`
+	// 因上次任务未结束或等待队列已满而跳过的次数
+	Skipped int `json:"skipped"`
+
+	spec cron.Schedule
+}
+
+// parseSpec 解析 cron 表达式或间隔
+func parseSpec(cronExpr string, interval string) (cron.Schedule, error) {
+	switch {
+	case cronExpr != "" && interval != "":
+		return nil, errors.New("cron 和 interval 只能指定一个")
+	case cronExpr != "":
+		spec, err := cron.ParseStandard(cronExpr)
+		if err != nil {
+			return nil, fmt.Errorf("cron 表达式不合法: %w", err)
+		}
+		return spec, nil
+	case interval != "":
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("间隔不合法（至少 1m）: %s", interval)
+		}
+		return cron.Every(d), nil
+	default:
+		return nil, errors.New("需要指定 cron 或 interval")
+	}
+}
+
+// Scheduler 定时采集计划的调度
+type Scheduler struct {
+	manager *Manager
+	path    string
+	logger  *zap.Logger
+
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+	// 计划变化时唤醒调度循环
+	wake chan struct{}
+}
+
+// NewScheduler 从 path 加载已保存的计划
+func NewScheduler(manager *Manager, path string, logger *zap.Logger) (*Scheduler, error) {
+	s := &Scheduler{
+		manager:   manager,
+		path:      path,
+		logger:    logger.Named("Scheduler"),
+		schedules: map[string]*Schedule{},
+		wake:      make(chan struct{}, 1),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var schedules []*Schedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, fmt.Errorf("定时计划文件解析失败: %w", err)
+	}
+	for _, schedule := range schedules {
+		spec, err := parseSpec(schedule.Cron, schedule.Interval)
+		if err != nil {
+			s.logger.Error("跳过无效的定时计划", zap.String("schedule", schedule.ID), zap.Error(err))
+			continue
+		}
+		schedule.spec = spec
+		s.schedules[schedule.ID] = schedule
+	}
+	return s, nil
+}
+
+// Add 新增计划
+func (s *Scheduler) Add(name string, target string, opts crawl.Options, cronExpr string, interval string) (*Schedule, error) {
+	spec, err := parseSpec(cronExpr, interval)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	schedule := &Schedule{
+		ID:        uuid.NewString(),
+		Name:      name,
+		URL:       target,
+		Options:   opts,
+		Cron:      cronExpr,
+		Interval:  interval,
+		CreatedAt: now,
+		NextRun:   spec.Next(now),
+		spec:      spec,
+	}
+
+	s.mu.Lock()
+	s.schedules[schedule.ID] = schedule
+	err = s.save()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return s.Get(schedule.ID)
+}
+
+// Remove 删除计划，已创建的任务不受影响
+func (s *Scheduler) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[id]; !ok {
+		return fmt.Errorf("定时计划不存在: %s", id)
+	}
+	delete(s.schedules, id)
+	return s.save()
+}
+
+// Get 查询计划
+func (s *Scheduler) Get(id string) (*Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return nil, fmt.Errorf("定时计划不存在: %s", id)
+	}
+	return s.view(schedule), nil
+}
+
+// List 按创建时间返回所有计划
+func (s *Scheduler) List() []*Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedules := make([]*Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, s.view(schedule))
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+	return schedules
+}
+
+// view 计划副本，附带上次任务的状态（调用方持有锁）
+func (s *Scheduler) view(schedule *Schedule) *Schedule {
+	v := *schedule
+	if t, ok := s.manager.Get(schedule.LastTaskID); ok {
+		v.LastStatus = t.GetStatus()
+	}
+	return &v
+}
+
+// Run 调度循环，阻塞至 ctx 取消
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.runDue(time.Now())
+
+		// 没有计划时只等待新增
+		wait := 24 * time.Hour
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// runDue 运行到期的计划，返回最近一次的运行时间
+func (s *Scheduler) runDue(now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	changed := false
+	for _, schedule := range s.schedules {
+		if !schedule.NextRun.After(now) {
+			s.fire(schedule, now)
+			schedule.NextRun = schedule.spec.Next(now)
+			changed = true
+		}
+		if next.IsZero() || schedule.NextRun.Before(next) {
+			next = schedule.NextRun
+		}
+	}
+	if changed {
+		if err := s.save(); err != nil {
+			s.logger.Error("定时计划保存失败", zap.Error(err))
+		}
+	}
+	return next
+}
+
+// fire 创建任务，上次的任务仍在运行时跳过（调用方持有锁）
+func (s *Scheduler) fire(schedule *Schedule, now time.Time) {
+	if t, ok := s.manager.Get(schedule.LastTaskID); ok {
+		if status := t.GetStatus(); status == crawl.TaskPending || status == crawl.TaskRunning {
+			schedule.Skipped++
+			s.logger.Info("上次任务未结束，跳过本次运行", zap.String("schedule", schedule.ID), zap.String("task", t.ID))
+			return
+		}
+	}
+	t, err := s.manager.Submit(schedule.URL, schedule.Options)
+	if err != nil {
+		schedule.Skipped++
+		s.logger.Warn("任务提交失败，跳过本次运行", zap.String("schedule", schedule.ID), zap.Error(err))
+		return
+	}
+	schedule.LastRun = now
+	schedule.LastTaskID = t.ID
+	s.logger.Info("定时任务已创建", zap.String("schedule", schedule.ID), zap.String("name", schedule.Name), zap.String("task", t.ID))
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// save 写入计划文件（调用方持有锁）
+func (s *Scheduler) save() error {
+	schedules := make([]*Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,65 @@
+package task
+
+import (
+	"go.uber.org/zap"
+	"path/filepath"
+	"seed-detect/internal/crawl"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	for _, c := range []struct {
+		cron, interval string
+		ok             bool
+	}{
+		{"0 * * * *", "", true},
+		{"@weekly", "", true},
+		{"", "1h", true},
+		{"", "10s", false},
+		{"0 * * * *", "1h", false},
+		{"61 * * * *", "", false},
+		{"", "", false},
+	} {
+		if _, err := parseSpec(c.cron, c.interval); (err == nil) != c.ok {
+			t.Errorf("parseSpec(%q, %q) = %v", c.cron, c.interval, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	manager := NewManager(nil, nil, nil, nil, nil, Config{}, zap.NewNop())
+	scheduler, err := NewScheduler(manager, path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hourly, err := scheduler.Add("通知公告", "https://www.example.gov.cn/col/col12/index.html",
+		crawl.Options{MaxDepth: 2, Mode: crawl.CrawlIncremental}, "0 * * * *", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := hourly.NextRun; next.Minute() != 0 || !next.After(time.Now()) || next.Sub(time.Now()) > time.Hour {
+		t.Errorf("next run = %v", next)
+	}
+	weekly, err := scheduler.Add("首页", "https://www.example.gov.cn/", crawl.Options{MaxDepth: 1}, "", "168h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后计划不变
+	reloaded, err := NewScheduler(manager, path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedules := reloaded.List()
+	if len(schedules) != 2 || schedules[0].ID != hourly.ID || schedules[0].Options.Mode != crawl.CrawlIncremental ||
+		!schedules[1].NextRun.Equal(weekly.NextRun) {
+		t.Fatalf("reloaded schedules = %+v", schedules)
+	}
+
+	if err := reloaded.Remove(hourly.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Get(hourly.ID); err == nil {
+		t.Error("removed schedule still exists")
+	}
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"seed-detect/internal/crawl"
+	"sort"
+	"time"
+)
+
+// 任务持久化
+// 任务的配置、状态、统计、失败原因及写入的正文地址在每次状态变化时保存，批量作业同样保存，
+// 重启后恢复：排队中的任务重新入队，运行中的任务标记为中断。
+// TaskStore 可替换为其他存储，默认使用 bbolt 单文件数据库
+
+// TaskStore 任务及批量作业的存储
+type TaskStore interface {
+	// SaveTasks 在一个事务中保存多个任务
+	SaveTasks(records ...*crawl.TaskRecord) error
+	// LoadTasks 按创建时间返回所有任务，无法解析的记录跳过并在 error 中返回
+	LoadTasks() ([]*crawl.TaskRecord, error)
+	DeleteTask(id string) error
+	SaveJob(job *Job) error
+	// LoadJobs 按创建时间返回所有批量作业，无法解析的记录跳过并在 error 中返回
+	LoadJobs() ([]*Job, error)
+	DeleteJob(id string) error
+	Close() error
+}
+
+var (
+	tasksBucket = []byte("tasks")
+	jobsBucket  = []byte("jobs")
+)
+
+// BoltTaskStore 基于 bbolt 的 TaskStore，值为 json
+type BoltTaskStore struct {
+	db *bbolt.DB
+}
+
+func NewBoltTaskStore(path string) (*BoltTaskStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	// 另一个进程持有数据库时不无限等待
+	db, err := bbolt.Open(path, 0644, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{tasksBucket, jobsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltTaskStore{db: db}, nil
+}
+
+func (s *BoltTaskStore) SaveTasks(records ...*crawl.TaskRecord) error {
+	values := make(map[string]any, len(records))
+	for _, record := range records {
+		values[record.Task.ID] = record
+	}
+	return s.put(tasksBucket, values)
+}
+
+func (s *BoltTaskStore) LoadTasks() ([]*crawl.TaskRecord, error) {
+	var records []*crawl.TaskRecord
+	var errs []error
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(tasksBucket).ForEach(func(k, v []byte) error {
+			record := &crawl.TaskRecord{}
+			if err := json.Unmarshal(v, record); err != nil || record.Task == nil {
+				errs = append(errs, fmt.Errorf("任务记录解析失败: %s", k))
+				return nil
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Task.CreatedAt.Before(records[j].Task.CreatedAt)
+	})
+	return records, errors.Join(append(errs, err)...)
+}
+
+func (s *BoltTaskStore) DeleteTask(id string) error {
+	return s.delete(tasksBucket, id)
+}
+
+func (s *BoltTaskStore) SaveJob(job *Job) error {
+	return s.put(jobsBucket, map[string]any{job.ID: job})
+}
+
+func (s *BoltTaskStore) LoadJobs() ([]*Job, error) {
+	var jobs []*Job
+	var errs []error
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			job := &Job{}
+			if err := json.Unmarshal(v, job); err != nil {
+				errs = append(errs, fmt.Errorf("批量作业记录解析失败: %s", k))
+				return nil
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, errors.Join(append(errs, err)...)
+}
+
+func (s *BoltTaskStore) DeleteJob(id string) error {
+	return s.delete(jobsBucket, id)
+}
+
+func (s *BoltTaskStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltTaskStore) put(bucket []byte, values map[string]any) error {
+	data := make(map[string][]byte, len(values))
+	for key, value := range values {
+		v, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		data[key] = v
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for key, v := range data {
+			if err := b.Put([]byte(key), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltTaskStore) delete(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
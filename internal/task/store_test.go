@@ -0,0 +1,89 @@
+package task
+
+import (
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"seed-detect/internal/crawl"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBoltTaskStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewBoltTaskStore(filepath.Join(dir, "tasks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	checkpoints, err := crawl.NewCheckpointStore(filepath.Join(dir, "checkpoints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	documents := crawl.NewDirDocumentSink(filepath.Join(dir, "documents"))
+
+	// 已结束两天的任务及其正文，另一个正文已被之后的任务覆盖
+	old := crawl.NewTask("https://www.example.gov.cn/", crawl.Options{MaxDepth: 2})
+	old.Finish(nil)
+	old.FinishedAt = time.Now().Add(-48 * time.Hour)
+	for _, doc := range []*crawl.Document{
+		{TaskID: old.ID, URL: "https://www.example.gov.cn/a.html"},
+		{TaskID: "newer", URL: "https://www.example.gov.cn/b.html"},
+	} {
+		old.RecordDocument(doc.URL)
+		if err := documents.Save(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 进程退出时仍在运行及排队的任务
+	running := crawl.NewTask("https://www.example.edu.cn/", crawl.Options{MaxDepth: 1})
+	running.Begin()
+	pending := crawl.NewTask("https://www.example.org.cn/", crawl.Options{MaxDepth: 1})
+	if err := store.SaveTasks(old.Record(), running.Record(), pending.Record()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveJob(&Job{ID: "job", TaskIDs: []string{old.ID}, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不启动任务，只检查恢复的状态
+	manager := NewManager(nil, nil, checkpoints, documents, store, Config{}, zap.NewNop())
+	manager.stop()
+	if err := manager.Restore(false); err != nil {
+		t.Fatal(err)
+	}
+	restored, ok := manager.Get(old.ID)
+	if !ok || restored.GetStatus() != crawl.TaskFinished || len(restored.DocumentURLs()) != 2 || restored.Documents != 2 {
+		t.Fatalf("restored = %+v", restored)
+	}
+	if task, _ := manager.Get(running.ID); task.GetStatus() != crawl.TaskInterrupted {
+		t.Errorf("running task restored as %s", task.GetStatus())
+	}
+	if stats := manager.QueueStats(); stats.Queued != 1 || stats.Tasks[0] != pending.ID {
+		t.Errorf("queue = %+v", stats)
+	}
+	if job, ok := manager.GetJob("job"); !ok || job.Progress.Done != 1 {
+		t.Errorf("job = %+v", job)
+	}
+
+	if n := manager.Cleanup(time.Now().Add(-24 * time.Hour)); n != 1 {
+		t.Fatalf("cleanup = %d", n)
+	}
+	if _, ok := manager.Get(old.ID); ok {
+		t.Error("expired task still listed")
+	}
+	if _, ok := manager.GetJob("job"); ok {
+		t.Error("job without tasks still listed")
+	}
+	records, err := store.LoadTasks()
+	if err != nil || len(records) != 2 {
+		t.Errorf("records = %d, %v", len(records), err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "documents", "*", "*.json"))
+	if len(files) != 1 {
+		t.Errorf("documents = %v", files)
+	} else if data, _ := os.ReadFile(files[0]); !strings.Contains(string(data), `"task_id":"newer"`) {
+		t.Errorf("kept document = %s", data)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"os/signal"
 	"seed-detect/internal/api"
 	"seed-detect/internal/crawl"
+	"seed-detect/internal/metrics"
+	"seed-detect/internal/task"
 	"seed-detect/internal/utils"
 	"syscall"
 	"time"
@@ -44,6 +46,79 @@ func (app *App) Run(args []string) {
 			Name:  "port",
 			Value: "6003",
 		},
+		&cli2.StringFlag{
+			Name:  "attachment-dir",
+			Value: "attachments",
+		},
+		&cli2.StringFlag{
+			Name:  "user-agent",
+			Usage: "user agent of crawl requests, its product token is matched against robots.txt",
+			Value: crawl.DefaultUserAgent,
+		},
+		&cli2.DurationFlag{
+			Name:  "robots-ttl",
+			Usage: "cache duration of robots.txt per host",
+			Value: crawl.DefaultRobotsTTL,
+		},
+		&cli2.StringFlag{
+			Name:  "document-dir",
+			Usage: "directory of extracted documents",
+			Value: "documents",
+		},
+		&cli2.StringFlag{
+			Name:  "state-dir",
+			Usage: "directory of per-page etag/last-modified/content hash used by incremental crawls",
+			Value: "state",
+		},
+		&cli2.StringFlag{
+			Name:  "checkpoint-dir",
+			Usage: "directory of crawl checkpoints used to resume tasks",
+			Value: "checkpoints",
+		},
+		&cli2.BoolFlag{
+			Name:  "resume-interrupted",
+			Usage: "resume tasks interrupted by the last shutdown on startup",
+			Value: true,
+		},
+		&cli2.StringFlag{
+			Name:  "task-db",
+			Usage: "embedded database file of tasks and batch jobs",
+			Value: "tasks.db",
+		},
+		&cli2.DurationFlag{
+			Name:  "task-retention",
+			Usage: "delete tasks with their checkpoints and documents this long after they finish, 0 keeps them forever",
+			Value: 30 * 24 * time.Hour,
+		},
+		&cli2.IntFlag{
+			Name:  "max-running-tasks",
+			Usage: "max crawl tasks running at the same time, others wait in the queue",
+			Value: task.DefaultMaxRunning,
+		},
+		&cli2.IntFlag{
+			Name:  "queue-size",
+			Usage: "max waiting tasks, submissions are rejected with 429 when full",
+			Value: task.DefaultQueueSize,
+		},
+		&cli2.IntFlag{
+			Name:  "batch-concurrency",
+			Usage: "max running or queued child tasks of all batch jobs",
+			Value: task.DefaultBatchConcurrency,
+		},
+		&cli2.StringFlag{
+			Name:  "schedule-file",
+			Usage: "file of scheduled recurring crawls",
+			Value: "schedules.json",
+		},
+		&cli2.StringFlag{
+			Name:  "templates",
+			Usage: "site template file or directory (yaml/json)",
+			Value: "templates",
+		},
+	}
+	cli.Commands = []*cli2.Command{
+		evalCommand(),
+		induceCommand(),
 	}
 	cli.Action = func(c *cli2.Context) error {
 		options := []fx.Option{
@@ -61,8 +136,91 @@ func (app *App) Run(args []string) {
 			}),
 		}
 		options = append(options,
+			// 监控指标
+			metrics.Module,
+			// 附件下载
+			fx.Provide(func() crawl.AttachmentSink {
+				return crawl.NewDirAttachmentSink(c.String("attachment-dir"))
+			}),
+			fx.Provide(crawl.NewAttachmentDownloader),
+			// 站点模板（热加载）
+			fx.Provide(func(logger *zap.Logger) (*crawl.TemplateStore, error) {
+				return crawl.NewTemplateStore(c.String("templates"), logger)
+			}),
+			fx.Invoke(func(store *crawl.TemplateStore) {
+				go store.Watch(app.ctx, 10*time.Second)
+			}),
+			// robots.txt 缓存
+			fx.Provide(func() *crawl.RobotsCache {
+				return crawl.NewRobotsCache(c.String("user-agent"), c.Duration("robots-ttl"))
+			}),
+			// 采集检查点
+			fx.Provide(func() (*crawl.CheckpointStore, error) {
+				return crawl.NewCheckpointStore(c.String("checkpoint-dir"))
+			}),
+			// 正文存储及增量采集的页面状态
+			fx.Provide(func() crawl.DocumentSink {
+				return crawl.NewDirDocumentSink(c.String("document-dir"))
+			}),
+			fx.Provide(func() (*crawl.PageStateStore, error) {
+				return crawl.NewPageStateStore(c.String("state-dir"))
+			}),
 			fx.Provide(crawl.NewSpider),
+			// 任务持久化，在任务管理退出之后关闭
+			fx.Provide(func(lc fx.Lifecycle) (task.TaskStore, error) {
+				store, err := task.NewBoltTaskStore(c.String("task-db"))
+				if err != nil {
+					return nil, err
+				}
+				lc.Append(fx.Hook{
+					OnStop: func(ctx context.Context) error {
+						return store.Close()
+					},
+				})
+				return store, nil
+			}),
+			fx.Provide(func() task.Config {
+				return task.Config{
+					MaxRunning:       c.Int("max-running-tasks"),
+					QueueSize:        c.Int("queue-size"),
+					BatchConcurrency: c.Int("batch-concurrency"),
+					Retention:        c.Duration("task-retention"),
+				}
+			}),
+			fx.Provide(task.NewManager),
+			// 启动时加载任务及检查点并定期清理过期任务，退出时中断运行中的任务并保存检查点
+			fx.Invoke(func(lc fx.Lifecycle, manager *task.Manager) {
+				lc.Append(fx.Hook{
+					OnStart: func(ctx context.Context) error {
+						if err := manager.Restore(c.Bool("resume-interrupted")); err != nil {
+							return err
+						}
+						go manager.RunCleanup(app.ctx, time.Hour)
+						return nil
+					},
+					OnStop: manager.Shutdown,
+				})
+			}),
+			// 定时采集
+			fx.Provide(func(manager *task.Manager, logger *zap.Logger) (*task.Scheduler, error) {
+				return task.NewScheduler(manager, c.String("schedule-file"), logger)
+			}),
+			// 在加载检查点之后启动，避免与恢复的任务重复运行
+			fx.Invoke(func(lc fx.Lifecycle, scheduler *task.Scheduler) {
+				lc.Append(fx.Hook{
+					OnStart: func(ctx context.Context) error {
+						go scheduler.Run(app.ctx)
+						return nil
+					},
+				})
+			}),
 			fx.Provide(api.NewTaskHandler),
+			fx.Provide(api.NewExtractHandler),
+			fx.Provide(api.NewTemplateHandler),
+			fx.Provide(api.NewDirectoryHandler),
+			fx.Provide(api.NewScheduleHandler),
+			fx.Provide(api.NewBatchHandler),
+			fx.Provide(api.NewMetricsHandler),
 			// 数据接收服务
 			fx.Provide(api.NewServer),
 			fx.Invoke(NewHttpServer),
@@ -82,7 +240,10 @@ func (app *App) Run(args []string) {
 		fmt.Printf("[Fx] Cleanly stopped\n")
 		return nil
 	}
-	_ = cli.RunContext(app.ctx, args)
+	if err := cli.RunContext(app.ctx, args); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func NewHttpServer(lc fx.Lifecycle, server *api.Server, logger *zap.Logger) {